@@ -0,0 +1,75 @@
+package configs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// usernamePattern matches public chat usernames without the leading "@"
+var usernamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{3,31}$`)
+
+// ChatTarget identifies the chat reports are sent to, either by numeric ID
+// or by the username of a public channel or group
+type ChatTarget struct {
+	ID       int64  // Numeric chat ID
+	Username string // Username including the leading "@", e.g. "@alerts"
+}
+
+// ChatID returns a target for a numeric chat ID
+func ChatID(id int64) ChatTarget {
+	return ChatTarget{ID: id}
+}
+
+// ChatUsername returns a target for a public chat username, with or
+// without the leading "@"
+func ChatUsername(username string) ChatTarget {
+	if username != "" && !strings.HasPrefix(username, "@") {
+		username = "@" + username
+	}
+	return ChatTarget{Username: username}
+}
+
+// ParseChatTarget parses a numeric chat ID such as "-1001234567890" or a
+// username such as "@alerts"
+func ParseChatTarget(s string) (ChatTarget, error) {
+	s = strings.TrimSpace(s)
+
+	var target ChatTarget
+	if strings.HasPrefix(s, "@") {
+		target = ChatUsername(s)
+	} else {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return ChatTarget{}, fmt.Errorf("invalid chat %q: want a numeric ID or an @username", s)
+		}
+		target = ChatID(id)
+	}
+
+	if err := target.Validate(); err != nil {
+		return ChatTarget{}, err
+	}
+	return target, nil
+}
+
+// Validate checks that the target holds exactly one valid identifier
+func (t ChatTarget) Validate() error {
+	switch {
+	case t.ID == 0 && t.Username == "":
+		return fmt.Errorf("chat ID or username is required")
+	case t.ID != 0 && t.Username != "":
+		return fmt.Errorf("chat target has both an ID and a username")
+	case t.Username != "" && !usernamePattern.MatchString(strings.TrimPrefix(t.Username, "@")):
+		return fmt.Errorf("invalid chat username %q", t.Username)
+	}
+	return nil
+}
+
+// String returns the ID or the username
+func (t ChatTarget) String() string {
+	if t.Username != "" {
+		return t.Username
+	}
+	return strconv.FormatInt(t.ID, 10)
+}
@@ -1,49 +1,258 @@
 package configs
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
 	"time"
+
+	"github.com/somosbytes/telegramity/internal/errors"
 )
 
 // Config holds the configuration for the Telegramity client
 type Config struct {
 	// Telegram Bot Configuration
-	BotToken string // Your bot token from @BotFather
-	ChatID   int64  // Chat ID where to send error messages
+	BotToken string     // Your bot token from @BotFather
+	Chat     ChatTarget // Chat where to send error messages, by ID or username
+	ChatID   int64      // Numeric ID of Chat, resolved when the client is created
+	Routes   []Route    // Send matching reports to other chats instead of ChatID
+
+	// Called once per chat when a group turns into a supergroup and its
+	// messages move to a new chat ID
+	OnChatMigrated func(oldChatID, newChatID int64)
 
 	// Client Configuration
-	Timeout    time.Duration // How long to wait for API calls
-	MaxRetries int           // Maximum number of retry attempts
-	RetryDelay time.Duration // Delay between retries
+	Timeout       time.Duration // How long to wait for API calls
+	MaxRetries    int           // Maximum number of retry attempts
+	RetryDelay    time.Duration // Delay before the first retry, doubled on each attempt
+	MaxRetryDelay time.Duration // Upper bound for the retry delay
 
 	// Rate Limiting
-	RateLimitPerSecond int // Messages per second limit
+	RateLimitPerSecond float64         // Messages per second limit (<= 0 disables limiting)
+	RateLimitBurst     int             // Messages that can be sent at once after being idle
+	RateLimitReserve   int             // Burst tokens kept for ReserveSeverity and above
+	ReserveSeverity    errors.Severity // Minimum severity allowed to use the reserve
 
 	// Message Configuration
-	MaxMessageLength  int  // Maximum message length (Telegram limit: 4096)
-	IncludeStackTrace bool // Whether to include stack traces
-	IncludeTimestamp  bool // Whether to include timestamps
+	MaxMessageLength  int       // Maximum message length (Telegram limit: 4096)
+	IncludeStackTrace bool      // Whether to include stack traces
+	IncludeTimestamp  bool      // Whether to include timestamps
+	SplitLongMessages bool      // Split oversized reports into several messages instead of truncating
+	Formatter         Formatter // Renders reports as messages (default HTML)
+
+	// Context Rendering
+	ContextMaxDepth       int // Nesting levels of maps and slices shown before collapsing them
+	ContextMaxValueLength int // Characters kept of each context value
+
+	// Message Templates
+	Templates map[string]MessageTemplate // HTML templates by error type, "" for every type
+
+	// Asynchronous Delivery
+	Async          bool           // Whether reports are queued and sent in the background
+	QueueSize      int            // Maximum number of queued reports
+	Workers        int            // Number of background delivery workers
+	OverflowPolicy OverflowPolicy // What to do when the queue is full
+
+	// Deduplication
+	DedupWindow      time.Duration // Suppress repeated reports within this window (0 disables)
+	DedupStackFrames int           // Stack frames included in the fingerprint
+
+	// Shutdown
+	ShutdownTimeout time.Duration // How long Close waits for pending reports
 
 	// Environment
 	Environment string // Environment name (dev, staging, prod)
 	AppName     string // Application name
 	AppVersion  string // Application version
+
+	// Delivery
+	TopicIDs           map[string]int  // Forum topic in ChatID by error type, "" for every other type
+	SilentBelow        errors.Severity // Reports less severe than this are sent without notification
+	QuietHours         QuietHours      // Daily period in which more reports are sent without notification
+	ProtectContent     bool            // Prevent forwarding and saving report messages
+	DisableLinkPreview bool            // Do not show link previews in report messages
+
+	// Attachments
+	AttachFormat   string          // Attach the complete report as AttachText or AttachJSON ("" disables)
+	AttachSeverity errors.Severity // Also attach reports at least this severe when they fit ("" only when cut)
+
+	// Context added to every report, overridden by per-report values
+	DefaultContext map[string]interface{}
+
+	// Enrichers add host and runtime metadata to every report sent
+	Enrichers []Enricher
+
+	// Processors inspect, modify or drop every report before it is sent
+	Processors []Processor
+
+	// Extractors fill reports from request-scoped values in the context
+	// passed to ReportError
+	Extractors []ContextExtractor
+
+	// Scrubbing
+	Scrub         bool             // Apply the built-in rules for credentials and personal data
+	ScrubKeys     []string         // Context keys whose values are redacted, matched as case-insensitive fragments
+	ScrubPatterns []*regexp.Regexp // Matches redacted from error text, stack traces and context values
+}
+
+// Enricher adds metadata to a report, usually by appending to its Runtime
+// fields. Enrichers run on the reporting goroutine.
+type Enricher func(report *errors.ErrorReport)
+
+// Route sends the reports that match all of its conditions to ChatIDs.
+// Conditions left empty match every report. A report matching several
+// routes is sent to the chats of each; one matching none goes to ChatID.
+type Route struct {
+	ErrorTypes    []string          // Any of these error types
+	MinSeverity   errors.Severity   // At least this severity
+	Environments  []string          // Any of these environments
+	ContextKeys   []string          // Context keys that must all be present
+	ContextValues map[string]string // Context values that must all match, compared with fmt.Sprint
+
+	ChatIDs []int64 // Destination chats
+	TopicID int     // Forum topic in the destination chats, 0 for the general topic
 }
 
+// QuietHours is a daily period, such as the night, in which reports less
+// severe than LoudSeverity are sent without notification. Start and End
+// are hours of the day in Location (UTC when nil); the period may wrap
+// around midnight. It is disabled when Start equals End.
+type QuietHours struct {
+	Start        int
+	End          int
+	Location     *time.Location
+	LoudSeverity errors.Severity // Reports at least this severe still notify (critical when empty)
+}
+
+// Contains reports whether t falls within the quiet hours
+func (q QuietHours) Contains(t time.Time) bool {
+	if q.Start == q.End {
+		return false
+	}
+	if q.Location != nil {
+		t = t.In(q.Location)
+	} else {
+		t = t.UTC()
+	}
+
+	hour := t.Hour()
+	if q.Start < q.End {
+		return hour >= q.Start && hour < q.End
+	}
+	return hour >= q.Start || hour < q.End
+}
+
+// ContextExtractor copies request-scoped values from ctx into a report. It
+// runs after options and context maps are applied, so it should only fill
+// fields that are still empty.
+type ContextExtractor func(ctx context.Context, report *errors.ErrorReport)
+
+// Processor inspects and may modify a report before it is deduplicated and
+// formatted. It returns the report to continue with, which may be a
+// different one, or nil or false to drop it. ctx is the context passed to
+// ReportError.
+type Processor func(ctx context.Context, report *errors.ErrorReport) (*errors.ErrorReport, bool)
+
+// Formats of the document attached to reports
+const (
+	AttachText = "txt"
+	AttachJSON = "json"
+)
+
+// OverflowPolicy decides what happens to a report when the async queue is full
+type OverflowPolicy string
+
+const (
+	OverflowDropNewest OverflowPolicy = "drop_newest" // Discard the incoming report
+	OverflowDropOldest OverflowPolicy = "drop_oldest" // Discard the oldest queued report
+	OverflowBlock      OverflowPolicy = "block"       // Wait until there is room in the queue
+)
+
 // DefaultConfig returns a default configuration
 func DefaultConfig() Config {
 	return Config{
-		Timeout:            30 * time.Second,
-		MaxRetries:         3,
-		RetryDelay:         1 * time.Second,
-		RateLimitPerSecond: 1,    // 1 message per second by default
-		MaxMessageLength:   4096, // Telegram message limit
-		IncludeStackTrace:  true,
-		IncludeTimestamp:   true,
-		Environment:        "development",
-		AppName:            "unknown",
-		AppVersion:         "1.0.0",
+		Timeout:               30 * time.Second,
+		MaxRetries:            3,
+		RetryDelay:            1 * time.Second,
+		MaxRetryDelay:         30 * time.Second,
+		RateLimitPerSecond:    1, // 1 message per second by default
+		RateLimitBurst:        3,
+		RateLimitReserve:      1,
+		ReserveSeverity:       errors.SeverityCritical,
+		MaxMessageLength:      4096, // Telegram message limit
+		IncludeStackTrace:     true,
+		IncludeTimestamp:      true,
+		ContextMaxDepth:       3,
+		ContextMaxValueLength: 256,
+		Async:                 false,
+		QueueSize:             100,
+		Workers:               1,
+		OverflowPolicy:        OverflowDropNewest,
+		DedupWindow:           0,
+		DedupStackFrames:      3,
+		ShutdownTimeout:       5 * time.Second,
+		Environment:           "development",
+		AppName:               "unknown",
+		AppVersion:            "1.0.0",
 	}
 }
 
+// MessageTemplate is the source of a text/template, given inline or as a
+// path to a file
+type MessageTemplate struct {
+	Text string
+	File string
+}
+
 // ConfigOption allows customizing the client configuration
 type ConfigOption func(*Config)
+
+// Validate checks that the configuration can be used to create a client
+func (c *Config) Validate() error {
+	if c.BotToken == "" {
+		return fmt.Errorf("bot token is required")
+	}
+	if err := c.Chat.Validate(); err != nil {
+		return err
+	}
+	for i, route := range c.Routes {
+		if len(route.ChatIDs) == 0 || slices.Contains(route.ChatIDs, 0) {
+			return fmt.Errorf("route %d needs non-zero chat IDs", i)
+		}
+	}
+	if q := c.QuietHours; q.Start < 0 || q.Start > 23 || q.End < 0 || q.End > 23 {
+		return fmt.Errorf("quiet hours must be between 0 and 23")
+	}
+	switch c.AttachFormat {
+	case "", AttachText, AttachJSON:
+	default:
+		return fmt.Errorf("unknown attachment format %q", c.AttachFormat)
+	}
+	if math.IsNaN(c.RateLimitPerSecond) || math.IsInf(c.RateLimitPerSecond, 0) {
+		return fmt.Errorf("rate limit must be a finite number of messages per second")
+	}
+	if c.RateLimitPerSecond > 0 {
+		if c.RateLimitBurst < 1 {
+			return fmt.Errorf("rate limit burst must be at least 1")
+		}
+		if c.RateLimitReserve < 0 || c.RateLimitReserve >= c.RateLimitBurst {
+			return fmt.Errorf("rate limit reserve must be between 0 and burst-1")
+		}
+	}
+	if c.Async {
+		if c.QueueSize <= 0 {
+			return fmt.Errorf("queue size must be positive")
+		}
+		if c.Workers <= 0 {
+			return fmt.Errorf("workers must be positive")
+		}
+		switch c.OverflowPolicy {
+		case OverflowDropNewest, OverflowDropOldest, OverflowBlock:
+		default:
+			return fmt.Errorf("unknown overflow policy %q", c.OverflowPolicy)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package configs
+
+import (
+	"github.com/somosbytes/telegramity/internal/dedup"
+	"github.com/somosbytes/telegramity/internal/errors"
+)
+
+// Parse modes understood by Telegram
+const (
+	ParseModeNone       = ""
+	ParseModeHTML       = "HTML"
+	ParseModeMarkdownV2 = "MarkdownV2"
+)
+
+// Message is a formatted message ready to be sent
+type Message struct {
+	Text      string // Message text, at most 4096 UTF-16 code units
+	ParseMode string // How Telegram parses Text
+	Truncated bool   // Part of the report was left out to fit the message
+}
+
+// Formatter renders reports and duplicate summaries as Telegram messages.
+// Messages are sent in order; each one must fit within Telegram's limit.
+type Formatter interface {
+	Format(report *errors.ErrorReport) ([]Message, error)
+	FormatSummary(summary dedup.Summary) ([]Message, error)
+}
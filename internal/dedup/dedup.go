@@ -0,0 +1,190 @@
+package dedup
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/somosbytes/telegramity/internal/errors"
+)
+
+var (
+	uuidPattern   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	hexPattern    = regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b`)
+	numberPattern = regexp.MustCompile(`\d+`)
+)
+
+// Fingerprint identifies reports that describe the same problem. It is
+// built from the error type, the error message with IDs and numbers
+// normalized away, and the top stack frames outside this module. A
+// fingerprint set on the report takes precedence.
+func Fingerprint(report *errors.ErrorReport, frames int) string {
+	if report.Fingerprint != "" {
+		return report.Fingerprint
+	}
+
+	h := sha1.New()
+	h.Write([]byte(report.ErrorType))
+	h.Write([]byte{0})
+	h.Write([]byte(NormalizeMessage(report.Error.Error())))
+	for _, frame := range topFrames(report.StackTrace, frames) {
+		h.Write([]byte{0})
+		h.Write([]byte(frame))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// NormalizeMessage replaces the parts of an error message that usually
+// differ between occurrences of the same error
+func NormalizeMessage(message string) string {
+	message = uuidPattern.ReplaceAllString(message, "<uuid>")
+	message = hexPattern.ReplaceAllString(message, "<hex>")
+	return numberPattern.ReplaceAllString(message, "<n>")
+}
+
+// topFrames returns up to n function names from a Go stack trace, skipping
+// frames that belong to the reporting SDK itself
+func topFrames(stackTrace string, n int) []string {
+	var frames []string
+	for _, line := range strings.Split(stackTrace, "\n") {
+		if len(frames) >= n {
+			break
+		}
+		if line == "" || line[0] == '\t' || line[0] == ' ' || strings.HasPrefix(line, "goroutine ") {
+			continue
+		}
+		if strings.Contains(line, "somosbytes/telegramity/internal/") || strings.Contains(line, "somosbytes/telegramity/pkg/") {
+			continue
+		}
+
+		// Drop call arguments such as "(0xc000010000, 0x1)"
+		if i := strings.LastIndex(line, "("); i > 0 {
+			line = line[:i]
+		}
+		frames = append(frames, line)
+	}
+	return frames
+}
+
+// Summary describes the duplicates of a report suppressed during one window
+type Summary struct {
+	Report    *errors.ErrorReport // The first report with this fingerprint
+	Count     int                 // Occurrences suppressed since Since
+	Since     time.Time           // Start of the summarized window
+	FirstSeen time.Time           // First occurrence of the fingerprint
+	LastSeen  time.Time           // Latest occurrence of the fingerprint
+}
+
+type entry struct {
+	report      *errors.ErrorReport
+	firstSeen   time.Time
+	lastSeen    time.Time
+	windowStart time.Time
+	suppressed  int
+}
+
+// Deduplicator suppresses reports whose fingerprint was already seen within
+// the window and collects them into periodic summaries
+type Deduplicator struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries map[string]*entry
+}
+
+func New(window time.Duration) *Deduplicator {
+	return &Deduplicator{
+		window:  window,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Observe records an occurrence of the report at its timestamp and returns
+// true when it is a duplicate that should not be sent. The deduplicator
+// keeps a copy of the report, so the caller may go on changing it.
+func (d *Deduplicator) Observe(fingerprint string, report *errors.ErrorReport) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := report.Timestamp
+	e, ok := d.entries[fingerprint]
+	if !ok || (now.Sub(e.windowStart) >= d.window && e.suppressed == 0) {
+		d.entries[fingerprint] = &entry{
+			report:      clone(report),
+			firstSeen:   now,
+			lastSeen:    now,
+			windowStart: now,
+		}
+		return false
+	}
+
+	e.suppressed++
+	if now.After(e.lastSeen) {
+		e.lastSeen = now
+	}
+	return true
+}
+
+// clone copies the report along with its context, runtime metadata and
+// chats, the parts that are filled in after deduplication
+func clone(report *errors.ErrorReport) *errors.ErrorReport {
+	c := *report
+	c.Context = maps.Clone(report.Context)
+	c.Runtime = slices.Clone(report.Runtime)
+	c.ChatIDs = slices.Clone(report.ChatIDs)
+	return &c
+}
+
+// Flush returns summaries for windows that ended by now and forgets
+// fingerprints that have been quiet for a whole window
+func (d *Deduplicator) Flush(now time.Time) []Summary {
+	return d.flush(func(e *entry) bool { return now.Sub(e.windowStart) >= d.window }, now)
+}
+
+// FlushAll returns summaries for every fingerprint with suppressed
+// duplicates, regardless of the window, and resets the deduplicator
+func (d *Deduplicator) FlushAll(now time.Time) []Summary {
+	summaries := d.flush(func(*entry) bool { return true }, now)
+
+	d.mu.Lock()
+	d.entries = make(map[string]*entry)
+	d.mu.Unlock()
+
+	return summaries
+}
+
+func (d *Deduplicator) flush(expired func(*entry) bool, now time.Time) []Summary {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var summaries []Summary
+	for fingerprint, e := range d.entries {
+		if !expired(e) {
+			continue
+		}
+		if e.suppressed == 0 {
+			delete(d.entries, fingerprint)
+			continue
+		}
+
+		summaries = append(summaries, Summary{
+			Report:    e.report,
+			Count:     e.suppressed,
+			Since:     e.windowStart,
+			FirstSeen: e.firstSeen,
+			LastSeen:  e.lastSeen,
+		})
+		e.windowStart = now
+		e.suppressed = 0
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].FirstSeen.Before(summaries[j].FirstSeen)
+	})
+	return summaries
+}
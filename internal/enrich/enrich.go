@@ -0,0 +1,123 @@
+package enrich
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/errors"
+)
+
+// All returns every built-in enricher, in the order their fields are shown
+func All() []configs.Enricher {
+	return []configs.Enricher{Host(), Go(), Build(), Goroutines(), Memory()}
+}
+
+// Host adds the hostname and process ID
+func Host() configs.Enricher {
+	host := sync.OnceValue(func() string {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "unknown"
+		}
+		return fmt.Sprintf("%s (pid %d)", hostname, os.Getpid())
+	})
+
+	return func(report *errors.ErrorReport) {
+		add(report, "host", host())
+	}
+}
+
+// Go adds the Go version and target platform
+func Go() configs.Enricher {
+	value := fmt.Sprintf("%s %s/%s", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	return func(report *errors.ErrorReport) {
+		add(report, "go", value)
+	}
+}
+
+// Build adds the main module version and the VCS revision it was built
+// from, when the binary carries build information
+func Build() configs.Enricher {
+	build := sync.OnceValue(func() string {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return ""
+		}
+		return buildValue(info)
+	})
+
+	return func(report *errors.ErrorReport) {
+		if value := build(); value != "" {
+			add(report, "build", value)
+		}
+	}
+}
+
+func buildValue(info *debug.BuildInfo) string {
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+
+	value := info.Main.Path
+	if info.Main.Version != "" {
+		value += " " + info.Main.Version
+	}
+	if revision != "" {
+		if len(revision) > 12 {
+			revision = revision[:12]
+		}
+		if modified == "true" {
+			revision += ", dirty"
+		}
+		value += " (" + revision + ")"
+	}
+	return value
+}
+
+// Goroutines adds the number of running goroutines
+func Goroutines() configs.Enricher {
+	return func(report *errors.ErrorReport) {
+		add(report, "goroutines", fmt.Sprint(runtime.NumGoroutine()))
+	}
+}
+
+// Memory adds heap usage, memory obtained from the OS and the number of
+// completed GC cycles. Reading memory stats briefly stops the world.
+func Memory() configs.Enricher {
+	return func(report *errors.ErrorReport) {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		add(report, "memory", fmt.Sprintf("heap %s, sys %s, %d GC",
+			formatBytes(stats.HeapAlloc), formatBytes(stats.Sys), stats.NumGC))
+	}
+}
+
+func add(report *errors.ErrorReport, name, value string) {
+	report.Runtime = append(report.Runtime, errors.RuntimeField{Name: name, Value: value})
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. "12.3 MiB"
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
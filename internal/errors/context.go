@@ -0,0 +1,40 @@
+package errors
+
+import (
+	"context"
+	"maps"
+)
+
+type userKey struct{}
+
+type tagsKey struct{}
+
+// ContextWithUser returns a copy of ctx carrying the ID of the affected user
+func ContextWithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+// UserFromContext returns the user ID set by ContextWithUser
+func UserFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userKey{}).(string)
+	return userID, ok
+}
+
+// ContextWithTags returns a copy of ctx carrying tags, merged with any tags
+// ctx already carries. New values win on conflicting keys; the map passed in
+// is copied.
+func ContextWithTags(ctx context.Context, tags map[string]interface{}) context.Context {
+	merged := maps.Clone(TagsFromContext(ctx))
+	if merged == nil {
+		merged = make(map[string]interface{}, len(tags))
+	}
+	maps.Copy(merged, tags)
+	return context.WithValue(ctx, tagsKey{}, merged)
+}
+
+// TagsFromContext returns the tags set by ContextWithTags. The map must not
+// be modified.
+func TagsFromContext(ctx context.Context) map[string]interface{} {
+	tags, _ := ctx.Value(tagsKey{}).(map[string]interface{})
+	return tags
+}
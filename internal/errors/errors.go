@@ -19,6 +19,27 @@ const (
 	SeverityCritical Severity = "critical" // Critical issues, immediate action required
 )
 
+// AtLeast reports whether s is as severe as other or more. Unknown
+// severities rank below SeverityLow.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() >= other.rank()
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityLow:
+		return 1
+	case SeverityMedium:
+		return 2
+	case SeverityHigh:
+		return 3
+	case SeverityCritical:
+		return 4
+	default:
+		return 0
+	}
+}
+
 // ErrorOption allows customizing error reporting behavior
 type ErrorOption func(*ErrorReport)
 
@@ -32,6 +53,9 @@ const (
 	ErrorTypeInternal   = "internal"
 	ErrorTypeRateLimit  = "rate_limit"
 	ErrorTypeTimeout    = "timeout"
+	ErrorTypePanic      = "panic"
+	ErrorTypeHTTP       = "http"
+	ErrorTypeLog        = "log"
 )
 
 // ErrorReport represents an error report to be sent
@@ -45,13 +69,33 @@ type ErrorReport struct {
 	UserID      string // Affected user (optional)
 	Environment string // dev, staging, prod (optional)
 	AppName     string // Application name (optional)
+	AppVersion  string // Application version (optional)
 
 	// Operational (Required)
 	Severity  Severity  // low, medium, high, critical
 	Timestamp time.Time // When it happened
 
 	// Custom Data (Optional)
-	Context map[string]interface{} // Additional metadata
+	Context     map[string]interface{} // Additional metadata
+	Fingerprint string                 // Groups related reports (optional)
+	Runtime     []RuntimeField         // Host and runtime metadata added by enrichers
+
+	// Tracing (Optional)
+	TraceID  string // Trace the error occurred in, hex encoded
+	SpanID   string // Span the error occurred in, hex encoded
+	TraceURL string // Link to the trace in a tracing UI
+
+	// Delivery
+	ChatIDs   []int64 // Chats the report is sent to, chosen by routing when empty
+	TopicID   int     // Forum topic in the default chat, chosen by error type when 0
+	Silent    bool    // Send without notification, regardless of severity
+	Protected bool    // Prevent forwarding and saving the messages
+}
+
+// RuntimeField is one line of a report's runtime section
+type RuntimeField struct {
+	Name  string // e.g. "host"
+	Value string // e.g. "web-1 (pid 4242)"
 }
 
 func CreateErrorReport(err error, errorType string, opts ...ErrorOption) *ErrorReport {
@@ -0,0 +1,43 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PanicError wraps a value recovered from a panic
+type PanicError struct {
+	Value interface{} // The value passed to panic
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is an error
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// PanicStackTrace trims a stack captured with debug.Stack() inside a
+// deferred recover so it starts at the frame that panicked, dropping the
+// recover machinery above it.
+func PanicStackTrace(stack []byte) string {
+	lines := strings.Split(string(stack), "\n")
+
+	for i, line := range lines {
+		if strings.HasPrefix(line, "panic(") {
+			// Skip the panic call and the file line that follows it
+			if i+2 < len(lines) {
+				return strings.Join(lines[i+2:], "\n")
+			}
+			break
+		}
+	}
+
+	// Not captured during a panic; return the stack unchanged
+	return string(stack)
+}
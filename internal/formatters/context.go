@@ -0,0 +1,169 @@
+package formatters
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultContextMaxDepth       = 3
+	defaultContextMaxValueLength = 256
+
+	contextIndent = "  "
+)
+
+// contextRenderer renders a report context as sorted "key: value" lines,
+// indenting nested maps and slices
+type contextRenderer struct {
+	maxDepth       int  // Nesting levels shown before collapsing a value
+	maxValueLength int  // UTF-16 code units kept of each value
+	cut            bool // Whether a value was shortened or collapsed
+}
+
+func newContextRenderer(maxDepth, maxValueLength int) contextRenderer {
+	if maxDepth <= 0 {
+		maxDepth = defaultContextMaxDepth
+	}
+	if maxValueLength <= 0 {
+		maxValueLength = defaultContextMaxValueLength
+	}
+	return contextRenderer{maxDepth: maxDepth, maxValueLength: maxValueLength}
+}
+
+// render returns the unescaped lines for values, and whether any value
+// had to be shortened or collapsed
+func (r contextRenderer) render(values map[string]interface{}) (string, bool) {
+	var b strings.Builder
+	r.writeMap(&b, reflect.ValueOf(values), 0)
+	return strings.TrimSuffix(b.String(), "\n"), r.cut
+}
+
+// writeEntry writes one "prefix value" line, followed by the entries of
+// the value if it is a map or a slice
+func (r *contextRenderer) writeEntry(b *strings.Builder, depth int, prefix string, v reflect.Value) {
+	indent := strings.Repeat(contextIndent, depth)
+	v = indirect(v)
+
+	if s, ok := r.scalar(v); ok {
+		fmt.Fprintf(b, "%s%s %s\n", indent, prefix, s)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		switch {
+		case v.Len() == 0:
+			fmt.Fprintf(b, "%s%s {}\n", indent, prefix)
+		case depth+1 >= r.maxDepth:
+			r.cut = true
+			fmt.Fprintf(b, "%s%s {… %d keys}\n", indent, prefix, v.Len())
+		default:
+			fmt.Fprintf(b, "%s%s\n", indent, prefix)
+			r.writeMap(b, v, depth+1)
+		}
+	default: // Slice or array
+		switch {
+		case v.Len() == 0:
+			fmt.Fprintf(b, "%s%s []\n", indent, prefix)
+		case depth+1 >= r.maxDepth:
+			r.cut = true
+			fmt.Fprintf(b, "%s%s [… %d items]\n", indent, prefix, v.Len())
+		default:
+			fmt.Fprintf(b, "%s%s\n", indent, prefix)
+			for i := 0; i < v.Len(); i++ {
+				r.writeEntry(b, depth+1, "-", v.Index(i))
+			}
+		}
+	}
+}
+
+// writeMap writes the entries of a map sorted by key
+func (r *contextRenderer) writeMap(b *strings.Builder, v reflect.Value, depth int) {
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		entries = append(entries, entry{key: fmt.Sprint(iter.Key().Interface()), value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	for _, e := range entries {
+		r.writeEntry(b, depth, r.truncate(e.key)+":", e.value)
+	}
+}
+
+// scalar renders values that fit on one line: everything but non-empty
+// maps, slices and arrays. Structs are rendered as JSON.
+func (r *contextRenderer) scalar(v reflect.Value) (string, bool) {
+	if !v.IsValid() {
+		return "<nil>", true
+	}
+
+	if v.CanInterface() {
+		switch x := v.Interface().(type) {
+		case time.Time:
+			return r.truncate(x.Format(time.RFC3339)), true
+		case []byte:
+			return r.truncate(string(x)), true
+		case error:
+			return r.truncate(x.Error()), true
+		case fmt.Stringer:
+			return r.truncate(x.String()), true
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return "", false
+	case reflect.Struct:
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return r.truncate(fmt.Sprintf("%+v", v.Interface())), true
+		}
+		return r.truncate(string(data)), true
+	case reflect.String:
+		return r.truncate(v.String()), true
+	default:
+		return r.truncate(fmt.Sprint(v.Interface())), true
+	}
+}
+
+// truncate shortens s to the maximum value length, quoting it if it spans
+// several lines so the layout is kept
+func (r *contextRenderer) truncate(s string) string {
+	if strings.ContainsAny(s, "\r\n") {
+		s = strconv.Quote(s)
+	}
+	truncated := truncateText(s, r.maxValueLength, "…")
+	if truncated != s {
+		r.cut = true
+	}
+	return truncated
+}
+
+// indirect follows pointers and interfaces, stopping at values that
+// implement error or fmt.Stringer themselves
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		if v.Kind() == reflect.Pointer && v.CanInterface() {
+			switch v.Interface().(type) {
+			case error, fmt.Stringer:
+				return v
+			}
+		}
+		v = v.Elem()
+	}
+	return v
+}
@@ -0,0 +1,158 @@
+package formatters
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/errors"
+)
+
+// Document limits are generous: the point of the attachment is to show
+// what the message had to leave out
+const (
+	documentContextMaxDepth       = 16
+	documentContextMaxValueLength = 64 * 1024
+)
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// ReportDocument renders the complete report as a file in the given
+// format, configs.AttachText or configs.AttachJSON, and returns the file
+// name and content
+func ReportDocument(report *errors.ErrorReport, format string) (string, []byte, error) {
+	name := "report"
+	if errorType := unsafeFileNameChars.ReplaceAllString(report.ErrorType, "_"); errorType != "" {
+		name += "-" + errorType
+	}
+	name += "-" + report.Timestamp.UTC().Format("20060102-150405")
+
+	switch format {
+	case configs.AttachText:
+		return name + ".txt", []byte(reportText(report)), nil
+	case configs.AttachJSON:
+		data, err := reportJSON(report)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to encode report: %w", err)
+		}
+		return name + ".json", data, nil
+	default:
+		return "", nil, fmt.Errorf("unknown attachment format %q", format)
+	}
+}
+
+// reportText renders every field of the report as plain text, with the
+// full stack trace and context
+func reportText(report *errors.ErrorReport) string {
+	var b strings.Builder
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+
+	field("Time", report.Timestamp.Format(time.RFC3339))
+	field("Type", report.ErrorType)
+	field("Error", report.Error.Error())
+	field("Severity", string(report.Severity))
+	field("User", report.UserID)
+	field("Environment", report.Environment)
+	field("App", strings.TrimSpace(report.AppName+" "+report.AppVersion))
+	field("Fingerprint", report.Fingerprint)
+	field("Trace", report.TraceID)
+	field("Span", report.SpanID)
+	field("Trace URL", report.TraceURL)
+
+	if len(report.Context) > 0 {
+		renderer := newContextRenderer(documentContextMaxDepth, documentContextMaxValueLength)
+		lines, _ := renderer.render(report.Context)
+		fmt.Fprintf(&b, "\nContext:\n%s\n", lines)
+	}
+
+	if len(report.Runtime) > 0 {
+		b.WriteString("\nRuntime:\n")
+		for _, rf := range report.Runtime {
+			fmt.Fprintf(&b, "%s: %s\n", rf.Name, rf.Value)
+		}
+	}
+
+	if report.StackTrace != "" {
+		fmt.Fprintf(&b, "\nStack Trace:\n%s\n", strings.TrimRight(report.StackTrace, "\n"))
+	}
+
+	return b.String()
+}
+
+type reportDocument struct {
+	Timestamp   time.Time              `json:"timestamp"`
+	ErrorType   string                 `json:"error_type"`
+	Error       string                 `json:"error"`
+	Severity    string                 `json:"severity,omitempty"`
+	UserID      string                 `json:"user_id,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	AppName     string                 `json:"app_name,omitempty"`
+	AppVersion  string                 `json:"app_version,omitempty"`
+	Fingerprint string                 `json:"fingerprint,omitempty"`
+	TraceID     string                 `json:"trace_id,omitempty"`
+	SpanID      string                 `json:"span_id,omitempty"`
+	TraceURL    string                 `json:"trace_url,omitempty"`
+	Context     map[string]interface{} `json:"context,omitempty"`
+	Runtime     map[string]string      `json:"runtime,omitempty"`
+	StackTrace  string                 `json:"stack_trace,omitempty"`
+}
+
+// reportJSON renders the report as indented JSON. Context values that
+// cannot be encoded as JSON are written as text.
+func reportJSON(report *errors.ErrorReport) ([]byte, error) {
+	doc := reportDocument{
+		Timestamp:   report.Timestamp,
+		ErrorType:   report.ErrorType,
+		Error:       report.Error.Error(),
+		Severity:    string(report.Severity),
+		UserID:      report.UserID,
+		Environment: report.Environment,
+		AppName:     report.AppName,
+		AppVersion:  report.AppVersion,
+		Fingerprint: report.Fingerprint,
+		TraceID:     report.TraceID,
+		SpanID:      report.SpanID,
+		TraceURL:    report.TraceURL,
+		StackTrace:  report.StackTrace,
+	}
+
+	if len(report.Context) > 0 {
+		doc.Context = make(map[string]interface{}, len(report.Context))
+		for k, v := range report.Context {
+			doc.Context[k] = jsonValue(v)
+		}
+	}
+	if len(report.Runtime) > 0 {
+		doc.Runtime = make(map[string]string, len(report.Runtime))
+		for _, rf := range report.Runtime {
+			doc.Runtime[rf.Name] = rf.Value
+		}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// jsonValue returns v if it encodes as JSON, and its text otherwise.
+// Errors are written as their message rather than as an empty object.
+func jsonValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case time.Time, json.Marshaler:
+		// Encoded by their own rules
+	case error:
+		return x.Error()
+	case fmt.Stringer:
+		return x.String()
+	}
+
+	if _, err := json.Marshal(v); err != nil {
+		return fmt.Sprintf("%+v", v)
+	}
+	return v
+}
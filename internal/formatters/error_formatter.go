@@ -5,59 +5,220 @@ import (
 	"strings"
 
 	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/dedup"
 	"github.com/somosbytes/telegramity/internal/errors"
 )
 
+// ErrorFormatter renders reports with the default layout in one of the
+// parse modes supported by Telegram
 type ErrorFormatter struct {
 	config *configs.Config
+	markup markup
 }
 
+// NewErrorFormatter creates an HTML formatter
 func NewErrorFormatter(config *configs.Config) *ErrorFormatter {
-	return &ErrorFormatter{
-		config: config,
+	return NewHTMLFormatter(config)
+}
+
+// NewHTMLFormatter creates a formatter for Telegram's HTML parse mode
+func NewHTMLFormatter(config *configs.Config) *ErrorFormatter {
+	return &ErrorFormatter{config: config, markup: htmlMarkup}
+}
+
+// NewMarkdownV2Formatter creates a formatter for Telegram's MarkdownV2
+// parse mode
+func NewMarkdownV2Formatter(config *configs.Config) *ErrorFormatter {
+	return &ErrorFormatter{config: config, markup: markdownV2Markup}
+}
+
+// NewPlainTextFormatter creates a formatter for messages sent without a
+// parse mode
+func NewPlainTextFormatter(config *configs.Config) *ErrorFormatter {
+	return &ErrorFormatter{config: config, markup: plainMarkup}
+}
+
+// Format implements configs.Formatter. Messages are marked as truncated
+// when part of the report was left out to fit them.
+func (f *ErrorFormatter) Format(report *errors.ErrorReport) ([]configs.Message, error) {
+	texts, truncated := f.format(report)
+	messages := f.messages(texts...)
+	for i := range messages {
+		messages[i].Truncated = truncated
+	}
+	return messages, nil
+}
+
+// format renders the report messages and reports whether any part of the
+// report was cut
+func (f *ErrorFormatter) format(report *errors.ErrorReport) ([]string, bool) {
+	sections, shrinkable, cut := f.reportSections(report)
+	if f.config.SplitLongMessages {
+		return splitSections(f.markup, sections, f.maxLength()), cut
 	}
+
+	cut = cut || utf16Len(joinSections(sections)) > f.maxLength()
+	return []string{joinSections(fitSections(sections, f.maxLength(), shrinkable...))}, cut
 }
 
-func (f *ErrorFormatter) FormatErrorReport(report *errors.ErrorReport) (string, error) {
-	message := "🚨 <b>Error Report</b>\n\n"
+// reportSections builds the sections of a report message, along with the
+// indexes of the sections to shorten first when it is too long and whether
+// stack frames or context values were already left out
+func (f *ErrorFormatter) reportSections(report *errors.ErrorReport) ([]section, []int, bool) {
+	m := f.markup
+	sections := []section{{label: "🚨 " + m.bold(m.escape("Error Report")) + "\n\n"}}
+	field := func(emoji, name, value string) int {
+		sections = append(sections, f.field(emoji, name, value))
+		return len(sections) - 1
+	}
 
 	if f.config.IncludeTimestamp {
-		message += fmt.Sprintf("⏰ <b>Time:</b> %s\n", report.Timestamp.Format("2006-01-02 15:04:05"))
+		field("⏰", "Time:", report.Timestamp.Format("2006-01-02 15:04:05"))
 	}
 
-	message += fmt.Sprintf("🔍 <b>Type:</b> %s\n", report.ErrorType)
+	typeIdx := field("🔍", "Type:", report.ErrorType)
 
-	message += fmt.Sprintf("❌ <b>Error:</b> %s\n", report.Error.Error())
+	errorIdx := field("❌", "Error:", report.Error.Error())
 
 	if report.Severity != "" {
-		message += fmt.Sprintf("⚠️ <b>Severity:</b> %s\n", report.Severity)
+		field("⚠️", "Severity:", string(report.Severity))
 	}
 
+	var shrinkable []int
+
 	if report.UserID != "" {
-		message += fmt.Sprintf("👤 <b>User:</b> %s\n", report.UserID)
+		shrinkable = append(shrinkable, field("👤", "User:", report.UserID))
 	}
 
 	if report.Environment != "" {
-		message += fmt.Sprintf("🌍 <b>Environment:</b> %s\n", report.Environment)
+		shrinkable = append(shrinkable, field("🌍", "Environment:", report.Environment))
 	}
 
 	if report.AppName != "" {
-		message += fmt.Sprintf("📱 <b>App:</b> %s\n", report.AppName)
+		app := report.AppName
+		if report.AppVersion != "" {
+			app += " " + report.AppVersion
+		}
+		shrinkable = append(shrinkable, field("📱", "App:", app))
 	}
 
+	if report.TraceID != "" {
+		trace := f.field("🧵", "Trace:", report.TraceID)
+		if report.TraceURL != "" {
+			trace.open, trace.close = m.link(report.TraceURL)
+		}
+		if report.SpanID != "" {
+			trace.end = m.escape(" (span "+report.SpanID+")") + "\n"
+		}
+		sections = append(sections, trace)
+	}
+
+	cut := false
+	contextIdx := -1
 	if len(report.Context) > 0 {
-		message += fmt.Sprintf("📋 <b>Context:</b> %+v\n", report.Context)
+		renderer := newContextRenderer(f.config.ContextMaxDepth, f.config.ContextMaxValueLength)
+		var context string
+		context, cut = renderer.render(report.Context)
+		sections = append(sections, f.block("📋", "Context:", context))
+		contextIdx = len(sections) - 1
+	}
+
+	runtimeIdx := -1
+	if len(report.Runtime) > 0 {
+		lines := make([]string, len(report.Runtime))
+		for i, rf := range report.Runtime {
+			lines[i] = rf.Name + ": " + rf.Value
+		}
+		sections = append(sections, f.block("🖥️", "Runtime:", strings.Join(lines, "\n")))
+		runtimeIdx = len(sections) - 1
 	}
 
+	stackIdx := -1
 	if f.config.IncludeStackTrace && report.StackTrace != "" {
-		stackTrace := f.formatStackTrace(report.StackTrace)
-		message += fmt.Sprintf("\n🔍 <b>Stack Trace:</b>\n<pre><code>%s</code></pre>", stackTrace)
+		stackTrace, stackCut := f.formatStackTrace(report.StackTrace)
+		cut = cut || stackCut
+		sections = append(sections, section{
+			label:  "\n🔍 " + m.bold(m.escape("Stack Trace:")) + "\n",
+			open:   m.codeOpen,
+			body:   m.escapeCode(stackTrace),
+			close:  m.codeClose,
+			marker: m.codeMarker,
+		})
+		stackIdx = len(sections) - 1
 	}
 
-	return message, nil
+	// Give up the stack trace first, then the context and runtime, and the
+	// error text only when nothing else is left
+	var order []int
+	for _, i := range []int{stackIdx, contextIdx, runtimeIdx} {
+		if i >= 0 {
+			order = append(order, i)
+		}
+	}
+	order = append(order, shrinkable...)
+	order = append(order, errorIdx, typeIdx)
+
+	return sections, order, cut
+}
+
+// FormatSummary renders the duplicates suppressed for one fingerprint
+func (f *ErrorFormatter) FormatSummary(summary dedup.Summary) ([]configs.Message, error) {
+	m := f.markup
+	sections := []section{
+		{label: "🔁 " + m.bold(m.escape("Repeated Error")) + "\n\n"},
+		f.field("🔍", "Type:", summary.Report.ErrorType),
+		f.field("❌", "Error:", summary.Report.Error.Error()),
+		f.field("📊", "Occurred:", fmt.Sprintf("%d more times since %s", summary.Count, summary.Since.Format("15:04"))),
+		f.field("⏮️", "First seen:", summary.FirstSeen.Format("2006-01-02 15:04:05")),
+		f.field("⏭️", "Last seen:", summary.LastSeen.Format("2006-01-02 15:04:05")),
+	}
+
+	return f.messages(joinSections(fitSections(sections, f.maxLength(), 2, 1))), nil
+}
+
+// field builds a one-line "label: value" section
+func (f *ErrorFormatter) field(emoji, name, value string) section {
+	return section{
+		label:  f.markup.label(emoji, name),
+		body:   f.markup.escape(value),
+		end:    "\n",
+		marker: f.markup.marker,
+	}
+}
+
+// block builds a section whose value starts on the line after its label
+func (f *ErrorFormatter) block(emoji, name, value string) section {
+	return section{
+		label:  strings.TrimSuffix(f.markup.label(emoji, name), " ") + "\n",
+		body:   f.markup.escape(value),
+		end:    "\n",
+		marker: f.markup.marker,
+	}
+}
+
+func (f *ErrorFormatter) messages(texts ...string) []configs.Message {
+	messages := make([]configs.Message, len(texts))
+	for i, text := range texts {
+		messages[i] = configs.Message{Text: text, ParseMode: f.markup.parseMode}
+	}
+	return messages
+}
+
+func (f *ErrorFormatter) maxLength() int {
+	return maxLength(f.config)
+}
+
+// maxLength returns the configured message limit, capped at Telegram's
+func maxLength(config *configs.Config) int {
+	if config.MaxMessageLength <= 0 || config.MaxMessageLength > telegramMaxMessageLength {
+		return telegramMaxMessageLength
+	}
+	return config.MaxMessageLength
 }
 
-func (f *ErrorFormatter) formatStackTrace(stackTrace string) string {
+// formatStackTrace marks up the lines of a stack trace, keeping the first
+// 20 and reporting whether any were left out
+func (f *ErrorFormatter) formatStackTrace(stackTrace string) (string, bool) {
 	lines := strings.Split(stackTrace, "\n")
 
 	var formattedLines []string
@@ -77,10 +238,11 @@ func (f *ErrorFormatter) formatStackTrace(stackTrace string) string {
 	}
 
 	maxLines := 20
-	if len(formattedLines) > maxLines {
+	cut := len(formattedLines) > maxLines
+	if cut {
 		formattedLines = formattedLines[:maxLines]
 		formattedLines = append(formattedLines, "...")
 	}
 
-	return strings.Join(formattedLines, "\n")
+	return strings.Join(formattedLines, "\n"), cut
 }
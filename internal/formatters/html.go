@@ -0,0 +1,24 @@
+package formatters
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	htmlTag     = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+)
+
+// escapeHTML escapes the characters Telegram's HTML parse mode treats as
+// markup
+func escapeHTML(s string) string {
+	return htmlEscaper.Replace(s)
+}
+
+// StripHTML turns an HTML formatted message into plain text, for resending
+// a message Telegram failed to parse
+func StripHTML(message string) string {
+	return html.UnescapeString(htmlTag.ReplaceAllString(message, ""))
+}
@@ -0,0 +1,219 @@
+package formatters
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// telegramMaxMessageLength is the Bot API limit, in UTF-16 code units
+	telegramMaxMessageLength = 4096
+
+	truncatedMarker = "… (truncated)"
+
+	// partHeaderReserve is the room kept in every part of a split message
+	// for its "(i/n)" header
+	partHeaderReserve = 24
+
+	// maxEntityLength bounds how far an "&" is treated as a possible entity
+	maxEntityLength = 12
+)
+
+// section is one part of a formatted message. The label, tags and end are
+// markup that is never cut; only the body can be truncated or split, and
+// every piece of a split body is wrapped in its own open/close tags.
+type section struct {
+	label  string // e.g. "❌ <b>Error:</b> "
+	open   string // Tags opened around the body
+	body   string // Escaped message content, never containing tags
+	close  string // Tags closing open
+	end    string // Trailing separator
+	marker string // Appended to the body when it is truncated
+}
+
+func (s section) String() string {
+	return s.label + s.open + s.body + s.close + s.end
+}
+
+// overhead is the length of everything in the section but its body
+func (s section) overhead() int {
+	return utf16Len(s.label + s.open + s.close + s.end)
+}
+
+func joinSections(sections []section) string {
+	var b strings.Builder
+	for _, s := range sections {
+		b.WriteString(s.String())
+	}
+	return b.String()
+}
+
+// fitSections shortens the bodies of the sections at the given indexes, in
+// order, until the message fits within limit. Sections whose body cannot
+// keep anything but the marker are dropped.
+func fitSections(sections []section, limit int, shrinkable ...int) []section {
+	sections = append([]section(nil), sections...)
+
+	excess := utf16Len(joinSections(sections)) - limit
+	for _, i := range shrinkable {
+		if excess <= 0 {
+			break
+		}
+
+		bodyLen := utf16Len(sections[i].body)
+		room := bodyLen - excess
+		if room <= utf16Len(sections[i].marker) {
+			excess -= bodyLen + sections[i].overhead()
+			sections[i] = section{}
+			continue
+		}
+
+		sections[i].body = truncateText(sections[i].body, room, sections[i].marker)
+		excess -= bodyLen - utf16Len(sections[i].body)
+	}
+
+	return sections
+}
+
+// splitSections packs the sections into as few messages as possible,
+// splitting bodies that do not fit in a message of their own. Every message
+// is prefixed with its position when more than one is needed.
+func splitSections(m markup, sections []section, limit int) []string {
+	if utf16Len(joinSections(sections)) <= limit {
+		return []string{joinSections(sections)}
+	}
+	limit -= partHeaderReserve
+
+	var parts []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			parts = append(parts, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, s := range sections {
+		if utf16Len(current.String())+utf16Len(s.String()) <= limit {
+			current.WriteString(s.String())
+			continue
+		}
+		flush()
+
+		if utf16Len(s.String()) <= limit {
+			current.WriteString(s.String())
+			continue
+		}
+
+		// Split the body, repeating the wrapping tags in every piece
+		label, body := s.label, s.body
+		for body != "" {
+			room := limit - utf16Len(label+s.open+s.close+s.end)
+			var chunk string
+			chunk, body = splitText(body, room)
+			parts = append(parts, label+s.open+chunk+s.close+s.end)
+			label = ""
+		}
+	}
+	flush()
+
+	for i := range parts {
+		parts[i] = m.partHeader(i+1, len(parts)) + parts[i]
+	}
+	return parts
+}
+
+// truncateText cuts text to at most limit UTF-16 code units, marker
+// included, at a point that does not break an entity or a character
+func truncateText(text string, limit int, marker string) string {
+	if utf16Len(text) <= limit {
+		return text
+	}
+
+	cut := safeCut(text, limit-utf16Len(marker))
+	return strings.TrimRight(text[:cut], " ") + marker
+}
+
+// splitText returns a leading chunk of text of at most limit UTF-16 code
+// units, preferably ending at a line break, and the remainder
+func splitText(text string, limit int) (string, string) {
+	if utf16Len(text) <= limit {
+		return text, ""
+	}
+
+	cut := safeCut(text, limit)
+	if cut == 0 {
+		// Not even one character fits; make progress anyway
+		_, cut = utf8.DecodeRuneInString(text)
+	}
+	return text[:cut], strings.TrimPrefix(text[cut:], "\n")
+}
+
+// safeCut returns the largest byte offset at which text can be cut so the
+// head is at most limit UTF-16 code units long, without cutting through a
+// rune, an HTML entity such as "&amp;" or a MarkdownV2 escape such as "\.".
+// Cutting after a line break is preferred.
+func safeCut(text string, limit int) int {
+	units, cut, newline := 0, 0, 0
+	entityStart := -1
+	escaped := false
+
+	for i, r := range text {
+		units += runeUTF16Len(r)
+		if units > limit {
+			break
+		}
+		end := i + utf8.RuneLen(r)
+
+		// Never separate a backslash from the character it escapes
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+
+		switch {
+		case r == '&':
+			entityStart = i
+		case entityStart >= 0 && r == ';':
+			entityStart = -1
+		case entityStart >= 0 && (!isEntityRune(r) || end-entityStart > maxEntityLength):
+			// Not an entity after all
+			entityStart = -1
+		}
+
+		if entityStart < 0 {
+			cut = end
+			if r == '\n' {
+				newline = end
+			}
+		}
+	}
+
+	// Only prefer the line break if it keeps at least half of the head
+	if newline > 0 && newline >= cut/2 {
+		return newline
+	}
+	return cut
+}
+
+func isEntityRune(r rune) bool {
+	return r == '#' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+// utf16Len returns the length of s in UTF-16 code units, which is how
+// Telegram measures message length
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeUTF16Len(r)
+	}
+	return n
+}
+
+func runeUTF16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,44 @@
+package formatters
+
+import "strings"
+
+var (
+	markdownV2Escaper = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+		"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+		"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+	markdownV2CodeEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+)
+
+// escapeMarkdownV2 escapes every character MarkdownV2 reserves for markup
+func escapeMarkdownV2(s string) string {
+	return markdownV2Escaper.Replace(s)
+}
+
+// escapeMarkdownV2Code escapes text inside a pre or code entity, where
+// only "`" and "\" are special
+func escapeMarkdownV2Code(s string) string {
+	return markdownV2CodeEscaper.Replace(s)
+}
+
+// StripMarkdownV2 turns a MarkdownV2 formatted message into plain text,
+// for resending a message Telegram failed to parse
+func StripMarkdownV2(message string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range message {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case strings.ContainsRune("*_~|`", r):
+			// Formatting delimiter
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
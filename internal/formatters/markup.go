@@ -0,0 +1,89 @@
+package formatters
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+)
+
+// markup describes how a parse mode escapes text and marks up the parts of
+// a report
+type markup struct {
+	parseMode  string
+	escape     func(string) string                   // Escapes plain text
+	escapeCode func(string) string                   // Escapes text inside a code block
+	bold       func(string) string                   // Wraps already escaped text
+	italic     func(string) string                   // Wraps already escaped text
+	link       func(url string) (open, close string) // Markup around link text
+	codeOpen   string
+	codeClose  string
+	marker     string // Appended to truncated text
+	codeMarker string // Appended to truncated code
+}
+
+var htmlMarkup = markup{
+	parseMode:  configs.ParseModeHTML,
+	escape:     escapeHTML,
+	escapeCode: escapeHTML,
+	bold:       func(s string) string { return "<b>" + s + "</b>" },
+	italic:     func(s string) string { return "<i>" + s + "</i>" },
+	link:       func(url string) (string, string) { return `<a href="` + html.EscapeString(url) + `">`, "</a>" },
+	codeOpen:   "<pre><code>",
+	codeClose:  "</code></pre>",
+	marker:     truncatedMarker,
+	codeMarker: truncatedMarker,
+}
+
+var markdownV2Markup = markup{
+	parseMode:  configs.ParseModeMarkdownV2,
+	escape:     escapeMarkdownV2,
+	escapeCode: escapeMarkdownV2Code,
+	bold:       func(s string) string { return "*" + s + "*" },
+	italic:     func(s string) string { return "_" + s + "_" },
+	link:       func(url string) (string, string) { return "[", "](" + markdownV2URLEscaper.Replace(url) + ")" },
+	codeOpen:   "```\n",
+	codeClose:  "\n```",
+	marker:     escapeMarkdownV2(truncatedMarker),
+	codeMarker: truncatedMarker,
+}
+
+var plainMarkup = markup{
+	parseMode:  configs.ParseModeNone,
+	escape:     func(s string) string { return s },
+	escapeCode: func(s string) string { return s },
+	bold:       func(s string) string { return s },
+	italic:     func(s string) string { return s },
+	link:       func(url string) (string, string) { return "", " (" + url + ")" },
+	marker:     truncatedMarker,
+	codeMarker: truncatedMarker,
+}
+
+// markdownV2URLEscaper escapes the URL part of an inline link, where only
+// ")" and "\" are special
+var markdownV2URLEscaper = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+
+// label renders a field label such as "❌ <b>Error:</b> "
+func (m markup) label(emoji, name string) string {
+	return emoji + " " + m.bold(m.escape(name)) + " "
+}
+
+// partHeader renders the "(i/n)" line that starts each part of a split
+// message
+func (m markup) partHeader(i, n int) string {
+	return m.italic(m.escape(fmt.Sprintf("(%d/%d)", i, n))) + "\n"
+}
+
+// PlainText converts a formatted message to plain text, for resending a
+// message Telegram failed to parse
+func PlainText(message configs.Message) configs.Message {
+	switch message.ParseMode {
+	case configs.ParseModeHTML:
+		message.Text = StripHTML(message.Text)
+	case configs.ParseModeMarkdownV2:
+		message.Text = StripMarkdownV2(message.Text)
+	}
+	message.ParseMode = configs.ParseModeNone
+	return message
+}
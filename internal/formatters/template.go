@@ -0,0 +1,129 @@
+package formatters
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/dedup"
+	"github.com/somosbytes/telegramity/internal/errors"
+)
+
+// templateFuncs are the helpers available to message templates. Except for
+// since, their output is escaped for Telegram's HTML parse mode, so it must
+// not be passed to escape again.
+var templateFuncs = template.FuncMap{
+	"escape": func(v interface{}) string {
+		return escapeHTML(fmt.Sprint(v))
+	},
+	"truncate": func(n int, v interface{}) string {
+		// Cut the raw text so an entity is never split, then escape it
+		return escapeHTML(truncateText(fmt.Sprint(v), n, "…"))
+	},
+	"code": func(v interface{}) string {
+		return "<code>" + escapeHTML(fmt.Sprint(v)) + "</code>"
+	},
+	"pre": func(v interface{}) string {
+		return "<pre>" + escapeHTML(fmt.Sprint(v)) + "</pre>"
+	},
+	"since": func(t time.Time) string {
+		return time.Since(t).Round(time.Second).String()
+	},
+}
+
+// TemplateFormatter renders reports with HTML text/templates, chosen by
+// error type. Reports without a template, summaries, and rendered messages
+// that are too long use the fallback formatter.
+type TemplateFormatter struct {
+	config    *configs.Config
+	templates map[string]*template.Template
+	fallback  configs.Formatter
+}
+
+// NewTemplateFormatter parses config.Templates, reading template files, and
+// checks that every template renders. config.Formatter, or the HTML
+// formatter if it is nil, becomes the fallback.
+func NewTemplateFormatter(config *configs.Config) (*TemplateFormatter, error) {
+	fallback := config.Formatter
+	if fallback == nil {
+		fallback = NewHTMLFormatter(config)
+	}
+
+	f := &TemplateFormatter{
+		config:    config,
+		templates: make(map[string]*template.Template, len(config.Templates)),
+		fallback:  fallback,
+	}
+
+	// Sorted, so the first error reported does not depend on map order
+	errorTypes := make([]string, 0, len(config.Templates))
+	for errorType := range config.Templates {
+		errorTypes = append(errorTypes, errorType)
+	}
+	sort.Strings(errorTypes)
+
+	for _, errorType := range errorTypes {
+		tmpl, err := parseTemplate(errorType, config.Templates[errorType])
+		if err != nil {
+			return nil, err
+		}
+
+		// Catch references to fields that do not exist
+		sample := errors.CreateErrorReport(fmt.Errorf("sample error"), errorType)
+		if err := tmpl.Execute(&strings.Builder{}, sample); err != nil {
+			return nil, fmt.Errorf("template for %q: %w", errorType, err)
+		}
+
+		f.templates[errorType] = tmpl
+	}
+
+	return f, nil
+}
+
+func parseTemplate(errorType string, source configs.MessageTemplate) (*template.Template, error) {
+	text := source.Text
+	if source.File != "" {
+		data, err := os.ReadFile(source.File)
+		if err != nil {
+			return nil, fmt.Errorf("template for %q: %w", errorType, err)
+		}
+		text = string(data)
+	}
+
+	tmpl, err := template.New(errorType).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template for %q: %w", errorType, err)
+	}
+	return tmpl, nil
+}
+
+// Format implements configs.Formatter
+func (f *TemplateFormatter) Format(report *errors.ErrorReport) ([]configs.Message, error) {
+	tmpl, ok := f.templates[report.ErrorType]
+	if !ok {
+		tmpl, ok = f.templates[""]
+	}
+	if !ok {
+		return f.fallback.Format(report)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, report); err != nil {
+		return f.fallback.Format(report)
+	}
+
+	text := strings.TrimSpace(b.String())
+	if text == "" || utf16Len(text) > maxLength(f.config) {
+		return f.fallback.Format(report)
+	}
+	return []configs.Message{{Text: text, ParseMode: configs.ParseModeHTML}}, nil
+}
+
+// FormatSummary implements configs.Formatter
+func (f *TemplateFormatter) FormatSummary(summary dedup.Summary) ([]configs.Message, error) {
+	return f.fallback.FormatSummary(summary)
+}
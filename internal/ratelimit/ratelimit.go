@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket that refills at a fixed rate up to its burst
+// size. A number of tokens can be reserved for priority callers, so that a
+// flood of normal requests cannot starve them.
+type Limiter struct {
+	mu      sync.Mutex
+	rate    float64 // Tokens per second, <= 0 means unlimited
+	burst   float64
+	reserve float64
+	tokens  float64
+	last    time.Time
+
+	// No tokens are handed out before this time, e.g. while Telegram's
+	// flood control asked the bot to wait
+	pausedUntil time.Time
+}
+
+// New creates a limiter with a full bucket. Normal callers can only take a
+// token while more than reserve tokens are left; priority callers can use
+// the whole bucket.
+func New(rate float64, burst, reserve int) *Limiter {
+	return &Limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		reserve: float64(reserve),
+		tokens:  float64(burst),
+		last:    time.Now(),
+	}
+}
+
+// Allow takes a token if one is available right now
+func (l *Limiter) Allow(priority bool) bool {
+	return l.take(time.Now(), priority) == 0
+}
+
+// PauseUntil stops every caller from taking tokens until t, such as when
+// Telegram answers with retry_after. An earlier t than the current pause
+// has no effect.
+func (l *Limiter) PauseUntil(t time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if t.After(l.pausedUntil) {
+		l.pausedUntil = t
+	}
+}
+
+// Wait blocks until a token is available or ctx is done
+func (l *Limiter) Wait(ctx context.Context, priority bool) error {
+	for {
+		wait := l.take(time.Now(), priority)
+		if wait == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// take refills the bucket and takes a token, returning zero on success or
+// how long to wait before a token could be available
+func (l *Limiter) take(now time.Time, priority bool) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+	if l.rate <= 0 {
+		return 0
+	}
+
+	if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+elapsed*l.rate)
+		l.last = now
+	}
+
+	needed := 1.0
+	if !priority {
+		needed += l.reserve
+	}
+	if l.tokens >= needed {
+		l.tokens--
+		return 0
+	}
+
+	missing := (needed - l.tokens) / l.rate
+	return time.Duration(math.Ceil(missing * float64(time.Second)))
+}
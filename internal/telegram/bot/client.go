@@ -2,19 +2,50 @@ package bot
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// BotClient defines the interface for Telegram bot operations
+// BotClient defines the interface for Telegram bot operations. Failed
+// Bot API calls return errors wrapping a *SendError.
 type BotClient interface {
+	// SendMessage sends an HTML formatted message
 	SendMessage(ctx context.Context, chatID int64, message string) error
 
+	// SendMessageWithOptions sends a message and returns its message ID
+	SendMessageWithOptions(ctx context.Context, chatID int64, message string, opts SendOptions) (int, error)
+
+	// SendDocument uploads data as a file named name and returns the ID of
+	// the message carrying it. The parse mode in opts is ignored.
+	SendDocument(ctx context.Context, chatID int64, name string, data []byte, opts SendOptions) (int, error)
+
+	// ResolveChat returns the numeric ID of a chat given by username,
+	// such as "@alerts"
+	ResolveChat(ctx context.Context, username string) (int64, error)
+
 	TestConnection(ctx context.Context) error
 }
 
+// Parse modes supported by the Bot API
+const (
+	ParseModeNone       = ""
+	ParseModeHTML       = tgbotapi.ModeHTML
+	ParseModeMarkdownV2 = tgbotapi.ModeMarkdownV2
+)
+
+// SendOptions controls how a message is sent
+type SendOptions struct {
+	ParseMode          string // How Telegram parses the message text
+	ThreadID           int    // Forum topic to post in, 0 for the general topic
+	Silent             bool   // Deliver without a notification sound
+	ProtectContent     bool   // Prevent forwarding and saving the message
+	DisableLinkPreview bool   // Do not show previews for links in the message
+	ReplyToMessageID   int    // Message to reply to, 0 for none
+}
+
 type botClient struct {
 	bot     *tgbotapi.BotAPI
 	timeout time.Duration
@@ -41,30 +72,92 @@ func NewBotClient(token string, timeout time.Duration) (BotClient, error) {
 }
 
 func (c *botClient) SendMessage(ctx context.Context, chatID int64, message string) error {
+	_, err := c.SendMessageWithOptions(ctx, chatID, message, SendOptions{ParseMode: ParseModeHTML})
+	return err
+}
+
+func (c *botClient) SendMessageWithOptions(ctx context.Context, chatID int64, message string, opts SendOptions) (int, error) {
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return 0, ctx.Err()
 	default:
 	}
 
 	// Validate inputs
 	if message == "" {
-		return fmt.Errorf("message cannot be empty")
+		return 0, fmt.Errorf("message cannot be empty")
+	}
+	if chatID == 0 {
+		return 0, fmt.Errorf("chat ID cannot be zero")
+	}
+
+	// tgbotapi.MessageConfig predates forum topics and protected content,
+	// so the request is built by hand
+	params := sendParams(chatID, opts)
+	params["text"] = message
+	params.AddNonEmpty("parse_mode", opts.ParseMode)
+	params.AddBool("disable_web_page_preview", opts.DisableLinkPreview)
+
+	resp, err := c.bot.MakeRequest("sendMessage", params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send message: %w", ClassifyError(err))
+	}
+
+	return sentMessageID(resp)
+}
+
+func (c *botClient) SendDocument(ctx context.Context, chatID int64, name string, data []byte, opts SendOptions) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
 	}
+
 	if chatID == 0 {
-		return fmt.Errorf("chat ID cannot be zero")
+		return 0, fmt.Errorf("chat ID cannot be zero")
 	}
 
-	msg := tgbotapi.NewMessage(chatID, message)
+	files := []tgbotapi.RequestFile{{Name: "document", Data: tgbotapi.FileBytes{Name: name, Bytes: data}}}
+	resp, err := c.bot.UploadFiles("sendDocument", sendParams(chatID, opts), files)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send document: %w", ClassifyError(err))
+	}
 
-	msg.ParseMode = "HTML"
+	return sentMessageID(resp)
+}
+
+// sendParams returns the parameters shared by the send methods
+func sendParams(chatID int64, opts SendOptions) tgbotapi.Params {
+	params := make(tgbotapi.Params)
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonZero("message_thread_id", opts.ThreadID)
+	params.AddNonZero("reply_to_message_id", opts.ReplyToMessageID)
+	params.AddBool("disable_notification", opts.Silent)
+	params.AddBool("protect_content", opts.ProtectContent)
+	return params
+}
 
-	_, err := c.bot.Send(msg)
+func sentMessageID(resp *tgbotapi.APIResponse) (int, error) {
+	var sent tgbotapi.Message
+	if err := json.Unmarshal(resp.Result, &sent); err != nil {
+		return 0, fmt.Errorf("failed to decode sent message: %w", err)
+	}
+	return sent.MessageID, nil
+}
+
+func (c *botClient) ResolveChat(ctx context.Context, username string) (int64, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+	}
+
+	chat, err := c.bot.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{SuperGroupUsername: username}})
 	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+		return 0, fmt.Errorf("failed to resolve chat %s: %w", username, ClassifyError(err))
 	}
 
-	return nil
+	return chat.ID, nil
 }
 
 func (c *botClient) TestConnection(ctx context.Context) error {
@@ -76,7 +169,7 @@ func (c *botClient) TestConnection(ctx context.Context) error {
 
 	_, err := c.bot.GetMe()
 	if err != nil {
-		return fmt.Errorf("failed to test bot connection: %w", err)
+		return fmt.Errorf("failed to test bot connection: %w", ClassifyError(err))
 	}
 
 	return nil
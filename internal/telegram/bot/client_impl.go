@@ -3,34 +3,82 @@ package bot
 import (
 	"context"
 	"fmt"
+	"math/rand/v2"
 	"sync"
 	"time"
 
 	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/dedup"
 	"github.com/somosbytes/telegramity/internal/errors"
 	"github.com/somosbytes/telegramity/internal/formatters"
+	"github.com/somosbytes/telegramity/internal/ratelimit"
+	"github.com/somosbytes/telegramity/internal/scrub"
 )
 
 type Client interface {
 	ReportError(ctx context.Context, err error, errorType string, opts ...errors.ErrorOption) error
 	ReportErrorWithContext(ctx context.Context, err error, errorType string, context map[string]interface{}, opts ...errors.ErrorOption) error
+	Stats() Stats
+
+	// Flush waits until every accepted report has been delivered or ctx is done
+	Flush(ctx context.Context) error
+	// Shutdown stops accepting reports and waits for pending ones until ctx is done
+	Shutdown(ctx context.Context) error
+	// Close is Shutdown bounded by the configured ShutdownTimeout
 	Close() error
 }
 
 type client struct {
 	config      *configs.Config
 	bot         BotClient
-	rateLimiter *time.Ticker
+	formatter   configs.Formatter
+	scrubber    *scrub.Scrubber
+	rateLimiter *ratelimit.Limiter
 	mu          sync.RWMutex
 	closed      bool
+
+	// Lifetime of the client; cancelled when Shutdown gives up or finishes
+	ctx     context.Context
+	cancel  context.CancelFunc
+	queue   chan *errors.ErrorReport
+	workers sync.WaitGroup
+	pending pending
+	stats   stats
+
+	// Duplicate suppression, only used when DedupWindow is set
+	dedup *dedup.Deduplicator
+
+	// New IDs of group chats that were upgraded to supergroups
+	migrationMu sync.Mutex
+	migrations  map[int64]int64
 }
 
-func NewClient(config *configs.Config, botClient BotClient, rateLimiter *time.Ticker) Client {
-	return &client{
+func NewClient(config *configs.Config, botClient BotClient, rateLimiter *ratelimit.Limiter) Client {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	formatter := config.Formatter
+	if formatter == nil {
+		formatter = formatters.NewHTMLFormatter(config)
+	}
+
+	c := &client{
 		config:      config,
 		bot:         botClient,
+		formatter:   formatter,
+		scrubber:    scrub.New(config),
 		rateLimiter: rateLimiter,
+		ctx:         ctx,
+		cancel:      cancel,
 	}
+
+	if config.Async {
+		c.startWorkers()
+	}
+	if config.DedupWindow > 0 {
+		c.startSummaries()
+	}
+
+	return c
 }
 
 func (c *client) ReportError(ctx context.Context, err error, errorType string, opts ...errors.ErrorOption) error {
@@ -38,22 +86,33 @@ func (c *client) ReportError(ctx context.Context, err error, errorType string, o
 }
 
 func (c *client) ReportErrorWithContext(ctx context.Context, err error, errorType string, context map[string]interface{}, opts ...errors.ErrorOption) error {
+	if err == nil {
+		return fmt.Errorf("error cannot be nil")
+	}
+
+	// Register the report as pending while holding the lock, so Shutdown
+	// cannot miss a report that got past the closed check.
 	c.mu.RLock()
 	if c.closed {
 		c.mu.RUnlock()
 		return fmt.Errorf("client is closed")
 	}
+	c.pending.add()
 	c.mu.RUnlock()
 
-	if err == nil {
-		return fmt.Errorf("error cannot be nil")
-	}
+	// Release the report unless it was handed to the queue, whose workers
+	// release it. Deferred, so a panicking processor or extractor cannot
+	// leave Flush and Shutdown waiting.
+	queued := false
+	defer func() {
+		if !queued {
+			c.pending.done()
+		}
+	}()
 
 	report := errors.CreateErrorReport(err, errorType, opts...)
-
-	if context != nil {
-		report.Context = context
-	}
+	report.Context = c.mergeContext(errors.TagsFromContext(ctx), report.Context, context)
+	c.extract(ctx, report)
 
 	if report.Environment == "" && c.config.Environment != "" {
 		report.Environment = c.config.Environment
@@ -61,50 +120,277 @@ func (c *client) ReportErrorWithContext(ctx context.Context, err error, errorTyp
 	if report.AppName == "" && c.config.AppName != "" {
 		report.AppName = c.config.AppName
 	}
+	if report.AppVersion == "" && c.config.AppVersion != "" {
+		report.AppVersion = c.config.AppVersion
+	}
 
-	select {
-	case <-c.rateLimiter.C:
-	case <-ctx.Done():
-		return ctx.Err()
+	report = c.process(ctx, report)
+	if report == nil {
+		return nil
+	}
+
+	// Scrub before deduplication, which keeps reports for its summaries
+	if c.scrubber != nil {
+		c.scrubber.Report(report)
+	}
+
+	if c.suppress(report) {
+		return nil
+	}
+
+	for _, enrich := range c.config.Enrichers {
+		enrich(report)
+	}
+	if c.scrubber != nil && len(c.config.Enrichers) > 0 {
+		c.scrubber.Runtime(report)
+	}
+
+	if len(report.ChatIDs) == 0 {
+		report.ChatIDs = c.route(report)
+	}
+
+	if c.config.Async {
+		queued = true
+		return c.enqueue(ctx, report)
+	}
+
+	ctx, cancel := c.withLifetime(ctx)
+	defer cancel()
+
+	err = c.deliver(ctx, report)
+	if err != nil {
+		c.stats.failed.Add(1)
+		return err
+	}
+	c.stats.delivered.Add(1)
+	return nil
+}
+
+// mergeContext combines the client's default context, the tags carried by
+// the request context, the context set by options and the context passed
+// to the call, later ones winning on conflicting keys. The result is a
+// copy, so callers may reuse their maps.
+func (c *client) mergeContext(fromTags, fromOptions, fromCall map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(c.config.DefaultContext)+len(fromTags)+len(fromOptions)+len(fromCall))
+	for _, values := range []map[string]interface{}{c.config.DefaultContext, fromTags, fromOptions, fromCall} {
+		for k, v := range values {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// extract fills the report from values carried by ctx: the user set with
+// ContextWithUser, then the configured extractors in order
+func (c *client) extract(ctx context.Context, report *errors.ErrorReport) {
+	if report.UserID == "" {
+		if userID, ok := errors.UserFromContext(ctx); ok {
+			report.UserID = userID
+		}
+	}
+
+	for _, extract := range c.config.Extractors {
+		extract(ctx, report)
+	}
+}
+
+// withLifetime returns a context that is also cancelled when the client
+// lifetime ends, so Shutdown can abort synchronous deliveries.
+func (c *client) withLifetime(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(c.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
 	}
+}
 
-	formatter := formatters.NewErrorFormatter(c.config)
-	message, err := formatter.FormatErrorReport(report)
+// deliver formats the report and sends the resulting messages in order to
+// each of its chats, followed by the complete report as a document when
+// one is attached. A failure in one chat does not stop delivery to the
+// others.
+func (c *client) deliver(ctx context.Context, report *errors.ErrorReport) error {
+	messages, err := c.formatter.Format(report)
 	if err != nil {
 		return fmt.Errorf("failed to format error report: %w", err)
 	}
 
-	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
-		err = c.bot.SendMessage(ctx, c.config.ChatID, message)
+	var document *attachment
+	if c.attach(report, messages) {
+		name, data, err := formatters.ReportDocument(report, c.config.AttachFormat)
+		if err != nil {
+			return err
+		}
+		document = &attachment{name: name, data: data}
+	}
+
+	chatIDs := report.ChatIDs
+	if len(chatIDs) == 0 {
+		chatIDs = []int64{c.config.ChatID}
+	}
+
+	opts := c.sendOptions(report)
+	priority := report.Severity.AtLeast(c.config.ReserveSeverity)
+	var failed []error
+	for _, chatID := range chatIDs {
+		opts.ThreadID = c.topic(report, chatID)
+		if err := c.deliverTo(ctx, chatID, messages, document, opts, priority); err != nil {
+			failed = append(failed, err)
+		}
+	}
+	return joinErrors(failed)
+}
+
+// attachment is a report rendered as a file
+type attachment struct {
+	name string
+	data []byte
+}
+
+// attach reports whether the complete report is attached as a document:
+// when the formatter had to leave part of it out, or when it is severe
+// enough
+func (c *client) attach(report *errors.ErrorReport, messages []configs.Message) bool {
+	if c.config.AttachFormat == "" {
+		return false
+	}
+	if c.config.AttachSeverity != "" && report.Severity.AtLeast(c.config.AttachSeverity) {
+		return true
+	}
+	for _, message := range messages {
+		if message.Truncated {
+			return true
+		}
+	}
+	return false
+}
+
+// deliverTo sends the messages to one chat, then the document as a reply
+// to the first message
+func (c *client) deliverTo(ctx context.Context, chatID int64, messages []configs.Message, document *attachment, opts SendOptions, priority bool) error {
+	firstID := 0
+	for i, message := range messages {
+		messageID, err := c.send(ctx, chatID, message, opts, priority)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			firstID = messageID
+		}
+	}
+
+	if document == nil {
+		return nil
+	}
+	opts.ReplyToMessageID = firstID
+	return c.sendDocument(ctx, chatID, document, opts, priority)
+}
+
+// send waits for the rate limiter and sends the message to the chat,
+// retrying failed sends up to MaxRetries times, and returns the ID of the
+// sent message. Priority messages may use the rate limit reserve. The
+// parse mode in opts is taken from the message.
+func (c *client) send(ctx context.Context, chatID int64, message configs.Message, opts SendOptions, priority bool) (int, error) {
+	if err := c.rateLimiter.Wait(ctx, priority); err != nil {
+		return 0, err
+	}
+
+	chatID = c.migratedChat(chatID)
+	migrated := false
+	for attempt := 0; ; attempt++ {
+		opts.ParseMode = message.ParseMode
+		messageID, err := c.bot.SendMessageWithOptions(ctx, chatID, message.Text, opts)
 		if err == nil {
-			break
+			return messageID, nil
+		}
+
+		// The group became a supergroup with a new ID; resend there once
+		if newChatID := MigrateToChatID(err); newChatID != 0 && !migrated {
+			c.migrateChat(chatID, newChatID)
+			chatID, migrated = newChatID, true
+			continue
 		}
 
-		if attempt == c.config.MaxRetries {
-			return fmt.Errorf("failed to send error report after %d attempts: %w", c.config.MaxRetries+1, err)
+		// Rather than losing the report, resend it as plain text if
+		// Telegram could not parse the formatting
+		if message.ParseMode != ParseModeNone && IsParseError(err) {
+			message = formatters.PlainText(message)
+			continue
 		}
 
-		select {
-		case <-time.After(c.config.RetryDelay):
-		case <-ctx.Done():
-			return ctx.Err()
+		if err := c.backoff(ctx, attempt, err); err != nil {
+			return 0, err
 		}
 	}
+}
 
-	return nil
+// sendDocument waits for the rate limiter and uploads the document to the
+// chat, retrying failed uploads like send
+func (c *client) sendDocument(ctx context.Context, chatID int64, document *attachment, opts SendOptions, priority bool) error {
+	if err := c.rateLimiter.Wait(ctx, priority); err != nil {
+		return err
+	}
+
+	chatID = c.migratedChat(chatID)
+	for attempt := 0; ; attempt++ {
+		_, err := c.bot.SendDocument(ctx, chatID, document.name, document.data, opts)
+		if err == nil {
+			return nil
+		}
+
+		if err := c.backoff(ctx, attempt, err); err != nil {
+			return err
+		}
+	}
 }
 
-func (c *client) Close() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// backoff waits before retrying a failed send, or returns the error to
+// give up with when err is permanent or the retries are used up. Flood
+// control applies to the whole bot, so every sender sharing the rate
+// limiter waits as long as Telegram asked.
+func (c *client) backoff(ctx context.Context, attempt int, err error) error {
+	if retryAfter := RetryAfter(err); retryAfter > 0 {
+		c.rateLimiter.PauseUntil(time.Now().Add(retryAfter))
+	}
+	if !IsRetryable(err) {
+		return fmt.Errorf("failed to send error report: %w", err)
+	}
+	if attempt >= c.config.MaxRetries {
+		return fmt.Errorf("failed to send error report after %d attempts: %w", attempt+1, err)
+	}
 
-	if c.closed {
+	timer := time.NewTimer(c.retryDelay(attempt, err))
+	select {
+	case <-timer.C:
 		return nil
+	case <-ctx.Done():
+		timer.Stop()
+		return ctx.Err()
 	}
+}
 
-	c.closed = true
-	if c.rateLimiter != nil {
-		c.rateLimiter.Stop()
+// retryDelay returns how long to wait before the next attempt: the delay
+// Telegram asked for, or an exponential backoff with jitter
+func (c *client) retryDelay(attempt int, err error) time.Duration {
+	if retryAfter := RetryAfter(err); retryAfter > 0 {
+		return retryAfter
 	}
-	return nil
+
+	delay := c.config.RetryDelay << attempt
+	if limit := c.config.MaxRetryDelay; limit > 0 && (delay <= 0 || delay > limit) {
+		delay = limit
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	// Equal jitter: keep half of the delay and randomize the other half
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+func (c *client) Stats() Stats {
+	s := c.stats.snapshot()
+	s.Queued = len(c.queue)
+	return s
 }
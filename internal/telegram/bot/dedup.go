@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"github.com/somosbytes/telegramity/internal/dedup"
+	"github.com/somosbytes/telegramity/internal/errors"
+)
+
+// startSummaries creates the deduplicator and launches the goroutine that
+// sends a summary of suppressed duplicates once per window
+func (c *client) startSummaries() {
+	c.dedup = dedup.New(c.config.DedupWindow)
+
+	c.workers.Add(1)
+	go func() {
+		defer c.workers.Done()
+
+		ticker := time.NewTicker(c.config.DedupWindow)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				c.sendSummaries(c.ctx, c.dedup.Flush(now))
+			case <-c.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// suppress reports whether the report duplicates one sent within the
+// dedup window
+func (c *client) suppress(report *errors.ErrorReport) bool {
+	if c.dedup == nil {
+		return false
+	}
+
+	fingerprint := dedup.Fingerprint(report, c.config.DedupStackFrames)
+	if report.Fingerprint == "" {
+		report.Fingerprint = fingerprint
+	}
+
+	if c.dedup.Observe(fingerprint, report) {
+		c.stats.suppressed.Add(1)
+		return true
+	}
+	return false
+}
+
+func (c *client) sendSummaries(ctx context.Context, summaries []dedup.Summary) {
+	for _, summary := range summaries {
+		messages, err := c.formatter.FormatSummary(summary)
+		if err != nil {
+			continue
+		}
+		// Decide on notifications as of the latest occurrence
+		latest := *summary.Report
+		latest.Timestamp = summary.LastSeen
+		opts := c.sendOptions(&latest)
+		for _, chatID := range c.route(summary.Report) {
+			opts.ThreadID = c.topic(summary.Report, chatID)
+			for _, message := range messages {
+				if _, err := c.send(ctx, chatID, message, opts, false); err != nil {
+					c.stats.failed.Add(1)
+					break
+				}
+			}
+		}
+	}
+}
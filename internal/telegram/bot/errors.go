@@ -0,0 +1,157 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrorKind classifies a failed Bot API call
+type ErrorKind string
+
+const (
+	KindRateLimited  ErrorKind = "rate_limited" // Flood control, retry after RetryAfter
+	KindUnauthorized ErrorKind = "unauthorized" // Invalid or revoked bot token
+	KindForbidden    ErrorKind = "forbidden"    // Bot blocked, kicked or lacking rights
+	KindBadRequest   ErrorKind = "bad_request"  // Chat not found, invalid message, etc.
+	KindNetwork      ErrorKind = "network"      // The request did not reach Telegram
+	KindServer       ErrorKind = "server"       // Telegram failed to handle the request
+	KindUnknown      ErrorKind = "unknown"
+)
+
+// SendError describes a failed Bot API call
+type SendError struct {
+	Kind            ErrorKind
+	Code            int           // Bot API error code, 0 for network errors
+	Description     string        // Bot API error description
+	RetryAfter      time.Duration // How long Telegram asked us to wait
+	MigrateToChatID int64         // New chat ID after a group became a supergroup
+	Err             error
+}
+
+func (e *SendError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("telegram %s error %d: %s", e.Kind, e.Code, e.Description)
+	}
+	return fmt.Sprintf("telegram %s error: %v", e.Kind, e.Err)
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether sending again may succeed
+func (e *SendError) Retryable() bool {
+	switch e.Kind {
+	case KindUnauthorized, KindForbidden, KindBadRequest:
+		return false
+	default:
+		return true
+	}
+}
+
+// ClassifyError converts an error returned by the Bot API library into a
+// *SendError. Errors that are already classified, and context errors, are
+// returned unchanged.
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var sendErr *SendError
+	if errors.As(err, &sendErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		return &SendError{
+			Kind:            apiErrorKind(apiErr),
+			Code:            apiErr.Code,
+			Description:     apiErr.Message,
+			RetryAfter:      time.Duration(apiErr.RetryAfter) * time.Second,
+			MigrateToChatID: apiErr.MigrateToChatID,
+			Err:             err,
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return &SendError{Kind: KindNetwork, Err: err}
+	}
+
+	return &SendError{Kind: KindUnknown, Err: err}
+}
+
+func apiErrorKind(err *tgbotapi.Error) ErrorKind {
+	switch {
+	case err.Code == 429 || err.RetryAfter > 0:
+		return KindRateLimited
+	case err.Code == 401:
+		return KindUnauthorized
+	case err.Code == 403:
+		return KindForbidden
+	case err.Code == 400 || err.Code == 404:
+		return KindBadRequest
+	case err.Code >= 500:
+		return KindServer
+	default:
+		return KindUnknown
+	}
+}
+
+// IsRetryable reports whether a failed send may succeed when retried.
+// Unclassified errors are assumed to be transient.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return sendErr.Retryable()
+	}
+	return true
+}
+
+// IsParseError reports whether Telegram rejected the message because it
+// could not parse its formatting
+func IsParseError(err error) bool {
+	var sendErr *SendError
+	return errors.As(err, &sendErr) && sendErr.Kind == KindBadRequest &&
+		strings.Contains(sendErr.Description, "can't parse entities")
+}
+
+// RetryAfter returns how long Telegram asked us to wait before retrying,
+// or zero if it did not say
+func RetryAfter(err error) time.Duration {
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return sendErr.RetryAfter
+	}
+	return 0
+}
+
+// MigrateToChatID returns the new ID of a group that was upgraded to a
+// supergroup, or zero if err is not about a migration
+func MigrateToChatID(err error) int64 {
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return sendErr.MigrateToChatID
+	}
+	return 0
+}
+
+// joinErrors wraps the failures of a report sent to several chats, or
+// returns nil when there were none
+func joinErrors(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,33 @@
+package bot
+
+// migratedChat returns the ID messages for chatID are sent to, following
+// group-to-supergroup migrations seen so far
+func (c *client) migratedChat(chatID int64) int64 {
+	c.migrationMu.Lock()
+	defer c.migrationMu.Unlock()
+
+	for i := 0; i < len(c.migrations); i++ {
+		newChatID, ok := c.migrations[chatID]
+		if !ok {
+			break
+		}
+		chatID = newChatID
+	}
+	return chatID
+}
+
+// migrateChat records that chatID now lives at newChatID. The first time a
+// chat migrates, OnChatMigrated is called so the new ID can be persisted.
+func (c *client) migrateChat(chatID, newChatID int64) {
+	c.migrationMu.Lock()
+	_, seen := c.migrations[chatID]
+	if c.migrations == nil {
+		c.migrations = make(map[int64]int64)
+	}
+	c.migrations[chatID] = newChatID
+	c.migrationMu.Unlock()
+
+	if !seen && c.config.OnChatMigrated != nil {
+		c.config.OnChatMigrated(chatID, newChatID)
+	}
+}
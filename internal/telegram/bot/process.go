@@ -0,0 +1,23 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/somosbytes/telegramity/internal/errors"
+)
+
+// process runs the configured processors in order, each on the report
+// returned by the previous one. It returns nil once a processor drops the
+// report, or replaces it with one without an error, which could not be
+// fingerprinted or formatted.
+func (c *client) process(ctx context.Context, report *errors.ErrorReport) *errors.ErrorReport {
+	for _, processor := range c.config.Processors {
+		next, keep := processor(ctx, report)
+		if !keep || next == nil || next.Error == nil {
+			c.stats.filtered.Add(1)
+			return nil
+		}
+		report = next
+	}
+	return report
+}
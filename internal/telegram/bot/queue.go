@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/errors"
+)
+
+// Stats holds delivery counters for a client
+type Stats struct {
+	Queued     int    // Reports currently waiting in the async queue
+	Delivered  uint64 // Reports sent successfully
+	Failed     uint64 // Reports that could not be sent
+	Dropped    uint64 // Reports discarded because the queue was full
+	Abandoned  uint64 // Reports still pending when Shutdown gave up
+	Suppressed uint64 // Duplicate reports folded into a summary
+	Filtered   uint64 // Reports dropped by a processor
+}
+
+type stats struct {
+	delivered  atomic.Uint64
+	failed     atomic.Uint64
+	dropped    atomic.Uint64
+	abandoned  atomic.Uint64
+	suppressed atomic.Uint64
+	filtered   atomic.Uint64
+}
+
+func (s *stats) snapshot() Stats {
+	return Stats{
+		Delivered:  s.delivered.Load(),
+		Failed:     s.failed.Load(),
+		Dropped:    s.dropped.Load(),
+		Abandoned:  s.abandoned.Load(),
+		Suppressed: s.suppressed.Load(),
+		Filtered:   s.filtered.Load(),
+	}
+}
+
+// startWorkers creates the async queue and launches the delivery workers
+func (c *client) startWorkers() {
+	c.queue = make(chan *errors.ErrorReport, c.config.QueueSize)
+
+	for i := 0; i < c.config.Workers; i++ {
+		c.workers.Add(1)
+		go c.worker()
+	}
+}
+
+func (c *client) worker() {
+	defer c.workers.Done()
+
+	for {
+		select {
+		case report := <-c.queue:
+			if err := c.deliver(c.ctx, report); err != nil {
+				c.stats.failed.Add(1)
+			} else {
+				c.stats.delivered.Add(1)
+			}
+			c.pending.done()
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// enqueue adds a report to the async queue, applying the configured
+// overflow policy when the queue is full. The report must already be
+// registered as pending; it is released again if it gets dropped.
+func (c *client) enqueue(ctx context.Context, report *errors.ErrorReport) error {
+	switch c.config.OverflowPolicy {
+	case configs.OverflowBlock:
+		select {
+		case c.queue <- report:
+			return nil
+		case <-ctx.Done():
+			c.drop()
+			return ctx.Err()
+		case <-c.ctx.Done():
+			c.drop()
+			return fmt.Errorf("client is closed")
+		}
+
+	case configs.OverflowDropOldest:
+		for {
+			select {
+			case c.queue <- report:
+				return nil
+			default:
+			}
+
+			// Make room by discarding the oldest report, unless a worker
+			// got to it first.
+			select {
+			case <-c.queue:
+				c.drop()
+			default:
+			}
+		}
+
+	default:
+		select {
+		case c.queue <- report:
+			return nil
+		default:
+			c.drop()
+			return fmt.Errorf("report queue is full")
+		}
+	}
+}
+
+func (c *client) drop() {
+	c.stats.dropped.Add(1)
+	c.pending.done()
+}
@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/errors"
+)
+
+// ResolveChat sets config.ChatID from config.Chat, looking up the numeric
+// ID of a chat given by username so that messages use the stable ID even
+// if the username changes later
+func ResolveChat(ctx context.Context, config *configs.Config, botClient BotClient) error {
+	if config.Chat.Username == "" {
+		config.ChatID = config.Chat.ID
+		return nil
+	}
+
+	chatID, err := botClient.ResolveChat(ctx, config.Chat.Username)
+	if err != nil {
+		return err
+	}
+	config.ChatID = chatID
+	return nil
+}
+
+// route returns the chats the report is sent to: those of every matching
+// route, or the default chat when no route matches
+func (c *client) route(report *errors.ErrorReport) []int64 {
+	var chatIDs []int64
+	for _, route := range c.config.Routes {
+		if !routeMatches(route, report) {
+			continue
+		}
+		for _, chatID := range route.ChatIDs {
+			if !slices.Contains(chatIDs, chatID) {
+				chatIDs = append(chatIDs, chatID)
+			}
+		}
+	}
+
+	if len(chatIDs) == 0 {
+		return []int64{c.config.ChatID}
+	}
+	return chatIDs
+}
+
+func routeMatches(route configs.Route, report *errors.ErrorReport) bool {
+	if len(route.ErrorTypes) > 0 && !slices.Contains(route.ErrorTypes, report.ErrorType) {
+		return false
+	}
+	if route.MinSeverity != "" && !report.Severity.AtLeast(route.MinSeverity) {
+		return false
+	}
+	if len(route.Environments) > 0 && !slices.Contains(route.Environments, report.Environment) {
+		return false
+	}
+	for _, key := range route.ContextKeys {
+		if _, ok := report.Context[key]; !ok {
+			return false
+		}
+	}
+	for key, want := range route.ContextValues {
+		value, ok := report.Context[key]
+		if !ok || fmt.Sprint(value) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// topic returns the forum topic of the report in chatID. Topic IDs only
+// exist within one forum, so the report and error type topics apply to the
+// default chat, and a route's topic to the chats of that route.
+func (c *client) topic(report *errors.ErrorReport, chatID int64) int {
+	if chatID == c.config.ChatID {
+		if report.TopicID != 0 {
+			return report.TopicID
+		}
+		if topicID, ok := c.config.TopicIDs[report.ErrorType]; ok {
+			return topicID
+		}
+		if topicID, ok := c.config.TopicIDs[""]; ok {
+			return topicID
+		}
+	}
+
+	for _, route := range c.config.Routes {
+		if route.TopicID != 0 && slices.Contains(route.ChatIDs, chatID) && routeMatches(route, report) {
+			return route.TopicID
+		}
+	}
+	return 0
+}
+
+// sendOptions returns how the messages of a report are sent to every chat:
+// whether it notifies, given its severity and the quiet hours, and whether
+// it is protected. The forum topic is set per chat by topic.
+func (c *client) sendOptions(report *errors.ErrorReport) SendOptions {
+	opts := SendOptions{
+		Silent:             report.Silent,
+		ProtectContent:     report.Protected || c.config.ProtectContent,
+		DisableLinkPreview: c.config.DisableLinkPreview,
+	}
+
+	if c.config.SilentBelow != "" && !report.Severity.AtLeast(c.config.SilentBelow) {
+		opts.Silent = true
+	}
+
+	quiet := c.config.QuietHours
+	if quiet.Contains(report.Timestamp) {
+		loud := quiet.LoudSeverity
+		if loud == "" {
+			loud = errors.SeverityCritical
+		}
+		if !report.Severity.AtLeast(loud) {
+			opts.Silent = true
+		}
+	}
+
+	return opts
+}
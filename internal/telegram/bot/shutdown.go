@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AbandonedError is returned by Shutdown and Flush when the deadline passes
+// before every pending report has been delivered
+type AbandonedError struct {
+	Abandoned int   // Reports still pending when the deadline passed
+	Err       error // The context error
+}
+
+func (e *AbandonedError) Error() string {
+	return fmt.Sprintf("%d error reports not delivered: %v", e.Abandoned, e.Err)
+}
+
+func (e *AbandonedError) Unwrap() error {
+	return e.Err
+}
+
+// pending counts reports that have been accepted but not yet delivered,
+// and lets callers wait for that count to reach zero
+type pending struct {
+	mu    sync.Mutex
+	count int
+	idle  chan struct{}
+}
+
+func (p *pending) add() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.count == 0 {
+		p.idle = make(chan struct{})
+	}
+	p.count++
+}
+
+func (p *pending) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.count--
+	if p.count == 0 {
+		close(p.idle)
+	}
+}
+
+func (p *pending) len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.count
+}
+
+// wait blocks until nothing is pending or ctx is done
+func (p *pending) wait(ctx context.Context) error {
+	for {
+		p.mu.Lock()
+		if p.count == 0 {
+			p.mu.Unlock()
+			return nil
+		}
+		idle := p.idle
+		p.mu.Unlock()
+
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return &AbandonedError{Abandoned: p.len(), Err: ctx.Err()}
+		}
+	}
+}
+
+func (c *client) Flush(ctx context.Context) error {
+	return c.pending.wait(ctx)
+}
+
+func (c *client) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	// Wait for queued and in-flight reports, then stop the workers. If the
+	// deadline passes first, cancelling c.ctx aborts whatever is left.
+	err := c.pending.wait(ctx)
+	if abandoned, ok := err.(*AbandonedError); ok {
+		c.stats.abandoned.Add(uint64(abandoned.Abandoned))
+	}
+
+	// Summarize duplicates still inside their window, so the counts are
+	// not lost
+	if c.dedup != nil {
+		c.sendSummaries(ctx, c.dedup.FlushAll(time.Now()))
+	}
+
+	c.cancel()
+	c.workers.Wait()
+	return err
+}
+
+func (c *client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.ShutdownTimeout)
+	defer cancel()
+	return c.Shutdown(ctx)
+}
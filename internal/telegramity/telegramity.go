@@ -1,30 +1,43 @@
 package telegramity
 
 import (
+	"context"
 	"fmt"
-	"time"
 
 	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/formatters"
+	"github.com/somosbytes/telegramity/internal/ratelimit"
 	"github.com/somosbytes/telegramity/internal/telegram/bot"
 )
 
 func NewClient(botToken string, chatID int64, options ...configs.ConfigOption) (bot.Client, error) {
+	return NewClientForChat(botToken, configs.ChatID(chatID), options...)
+}
+
+// NewClientForChat creates a client for a chat given by numeric ID or by
+// username. Usernames are resolved to the chat's ID once, here.
+func NewClientForChat(botToken string, chat configs.ChatTarget, options ...configs.ConfigOption) (bot.Client, error) {
 	// Create default configuration
 	config := configs.DefaultConfig()
 	config.BotToken = botToken
-	config.ChatID = chatID
+	config.Chat = chat
 
 	// Apply configuration options
 	for _, option := range options {
 		option(&config)
 	}
 
-	// Validate required fields
-	if config.BotToken == "" {
-		return nil, fmt.Errorf("bot token is required")
+	// Validate the configuration
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
-	if config.ChatID == 0 {
-		return nil, fmt.Errorf("chat ID is required")
+
+	if len(config.Templates) > 0 {
+		formatter, err := formatters.NewTemplateFormatter(&config)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message template: %w", err)
+		}
+		config.Formatter = formatter
 	}
 
 	// Create the internal client implementation
@@ -39,8 +52,14 @@ func newClient(config *configs.Config) (bot.Client, error) {
 		return nil, fmt.Errorf("failed to create bot client: %w", err)
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+	if err := bot.ResolveChat(ctx, config, botClient); err != nil {
+		return nil, err
+	}
+
 	// Create rate limiter
-	rateLimiter := time.NewTicker(time.Duration(1000/config.RateLimitPerSecond) * time.Millisecond)
+	rateLimiter := ratelimit.New(config.RateLimitPerSecond, config.RateLimitBurst, config.RateLimitReserve)
 
 	// Create the main client implementation
 	client := bot.NewClient(config, botClient, rateLimiter)
@@ -1,9 +1,50 @@
 package telegramity
 
 import (
+	"math"
 	"time"
 
 	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/errors"
+)
+
+// ConfigOption customizes a client, for packages that build options of
+// their own on top of these
+type ConfigOption = configs.ConfigOption
+
+// Route sends reports matching all of its conditions to other chats
+type Route = configs.Route
+
+// ChatTarget identifies a chat by numeric ID or by username
+type ChatTarget = configs.ChatTarget
+
+// ChatID returns a target for a numeric chat ID
+func ChatID(id int64) ChatTarget {
+	return configs.ChatID(id)
+}
+
+// ChatUsername returns a target for the username of a public channel or
+// group, with or without the leading "@"
+func ChatUsername(username string) ChatTarget {
+	return configs.ChatUsername(username)
+}
+
+// ParseChatTarget parses a numeric chat ID or an @username, for example
+// from an environment variable
+func ParseChatTarget(s string) (ChatTarget, error) {
+	return configs.ParseChatTarget(s)
+}
+
+// Formats of the complete report attached by WithReportAttachment
+const (
+	AttachText = configs.AttachText // Plain text with the full stack trace and context
+	AttachJSON = configs.AttachJSON // Every field of the report as JSON
+)
+
+const (
+	OverflowDropNewest = configs.OverflowDropNewest // Discard the incoming report
+	OverflowDropOldest = configs.OverflowDropOldest // Discard the oldest queued report
+	OverflowBlock      = configs.OverflowBlock      // Wait until there is room in the queue
 )
 
 func WithTimeout(timeout time.Duration) configs.ConfigOption {
@@ -24,9 +65,76 @@ func WithRetryDelay(delay time.Duration) configs.ConfigOption {
 	}
 }
 
+// WithMaxRetryDelay caps the exponential backoff between retries
+func WithMaxRetryDelay(delay time.Duration) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.MaxRetryDelay = delay
+	}
+}
+
 func WithRateLimit(limit int) configs.ConfigOption {
 	return func(c *configs.Config) {
-		c.RateLimitPerSecond = limit
+		c.RateLimitPerSecond = float64(limit)
+	}
+}
+
+// WithRateLimitPer allows n messages per interval, e.g. 20 per minute to
+// stay within Telegram's group limits. The interval must be positive.
+func WithRateLimitPer(n int, interval time.Duration) configs.ConfigOption {
+	return func(c *configs.Config) {
+		if interval <= 0 {
+			c.RateLimitPerSecond = math.NaN() // Rejected when the client is created
+			return
+		}
+		c.RateLimitPerSecond = float64(n) / interval.Seconds()
+	}
+}
+
+// WithRateLimitBurst sets how many messages can be sent at once after the
+// client has been idle
+func WithRateLimitBurst(burst int) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.RateLimitBurst = burst
+	}
+}
+
+// WithRateLimitReserve keeps reserve burst tokens for reports of at least
+// the given severity, so floods of less severe reports cannot starve them
+func WithRateLimitReserve(reserve int, severity errors.Severity) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.RateLimitReserve = reserve
+		c.ReserveSeverity = severity
+	}
+}
+
+// WithAsync queues reports and delivers them from background workers,
+// so ReportError returns without waiting for Telegram.
+func WithAsync(workers, queueSize int) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.Async = true
+		c.Workers = workers
+		c.QueueSize = queueSize
+	}
+}
+
+func WithOverflowPolicy(policy configs.OverflowPolicy) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.OverflowPolicy = policy
+	}
+}
+
+// WithDeduplication suppresses reports with the same fingerprint seen
+// within window and sends a summary of the suppressed ones once per window
+func WithDeduplication(window time.Duration) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.DedupWindow = window
+	}
+}
+
+// WithShutdownTimeout sets how long Close waits for pending reports
+func WithShutdownTimeout(timeout time.Duration) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.ShutdownTimeout = timeout
 	}
 }
 
@@ -36,6 +144,20 @@ func WithEnvironmentName(env string) configs.ConfigOption {
 	}
 }
 
+// WithDefaultContext adds values, such as the hostname, region or pod
+// name, to the context of every report. Values given for a single report
+// take precedence.
+func WithDefaultContext(values map[string]interface{}) configs.ConfigOption {
+	return func(c *configs.Config) {
+		if c.DefaultContext == nil {
+			c.DefaultContext = make(map[string]interface{}, len(values))
+		}
+		for k, v := range values {
+			c.DefaultContext[k] = v
+		}
+	}
+}
+
 func WithAppInfo(name, version string) configs.ConfigOption {
 	return func(c *configs.Config) {
 		c.AppName = name
@@ -50,3 +172,95 @@ func WithMessageConfig(includeStackTrace, includeTimestamp bool, maxLength int)
 		c.MaxMessageLength = maxLength
 	}
 }
+
+// WithContextLimits bounds how report context is rendered: maps and slices
+// nested deeper than maxDepth are collapsed, and values are cut to
+// maxValueLength characters
+func WithContextLimits(maxDepth, maxValueLength int) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.ContextMaxDepth = maxDepth
+		c.ContextMaxValueLength = maxValueLength
+	}
+}
+
+// WithMessageSplitting sends reports longer than the maximum message length
+// as several ordered messages instead of truncating them
+func WithMessageSplitting(split bool) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.SplitLongMessages = split
+	}
+}
+
+// WithRoutes sends reports to chats chosen by error type, severity,
+// environment and context. A report is sent to the chats of every route it
+// matches, and to the default chat when it matches none.
+func WithRoutes(routes ...Route) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.Routes = append(c.Routes, routes...)
+	}
+}
+
+// WithForumTopic posts reports of errorType in a topic of the default
+// chat, which must be a forum. An empty errorType sets the topic for every
+// type without its own. Routed chats use the TopicID of their Route.
+func WithForumTopic(errorType string, topicID int) configs.ConfigOption {
+	return func(c *configs.Config) {
+		if c.TopicIDs == nil {
+			c.TopicIDs = make(map[string]int)
+		}
+		c.TopicIDs[errorType] = topicID
+	}
+}
+
+// WithSilentBelow sends reports less severe than severity without a
+// notification sound
+func WithSilentBelow(severity errors.Severity) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.SilentBelow = severity
+	}
+}
+
+// WithQuietHours sends reports less severe than loudSeverity without a
+// notification between the start and end hours in loc, e.g. 22 to 7. A
+// nil loc means UTC.
+func WithQuietHours(start, end int, loc *time.Location, loudSeverity errors.Severity) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.QuietHours = configs.QuietHours{Start: start, End: end, Location: loc, LoudSeverity: loudSeverity}
+	}
+}
+
+// WithProtectContent prevents report messages from being forwarded or
+// saved
+func WithProtectContent(protect bool) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.ProtectContent = protect
+	}
+}
+
+// WithLinkPreviews shows or hides previews of links in report messages
+func WithLinkPreviews(show bool) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.DisableLinkPreview = !show
+	}
+}
+
+// WithChatMigrationHandler is called once per chat when a group is
+// upgraded to a supergroup. Reports are already resent to the new chat ID;
+// use the handler to persist it in your configuration.
+func WithChatMigrationHandler(handler func(oldChatID, newChatID int64)) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.OnChatMigrated = handler
+	}
+}
+
+// WithReportAttachment attaches the complete report, with the full stack
+// trace, context and runtime information, as a document replying to the
+// message. It is attached when the message had to leave part of the report
+// out, and for every report at least as severe as severity unless severity
+// is empty.
+func WithReportAttachment(format string, severity errors.Severity) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.AttachFormat = format
+		c.AttachSeverity = severity
+	}
+}
@@ -0,0 +1,52 @@
+package telegramity
+
+import (
+	"context"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/errors"
+)
+
+// ContextExtractor copies request-scoped values from the context passed to
+// ReportError into a report
+type ContextExtractor = configs.ContextExtractor
+
+// ContextWithUser returns a copy of ctx carrying the ID of the affected
+// user. Reports made with it use that ID unless WithUserID is given.
+func ContextWithUser(ctx context.Context, userID string) context.Context {
+	return errors.ContextWithUser(ctx, userID)
+}
+
+// ContextWithTags returns a copy of ctx carrying tags, such as a request ID
+// or tenant, that are added to the context of reports made with it. Tags
+// accumulate across calls; values given for a single report take
+// precedence.
+func ContextWithTags(ctx context.Context, tags map[string]interface{}) context.Context {
+	return errors.ContextWithTags(ctx, tags)
+}
+
+// ContextWithTag is ContextWithTags for a single tag
+func ContextWithTag(ctx context.Context, key string, value interface{}) context.Context {
+	return errors.ContextWithTags(ctx, map[string]interface{}{key: value})
+}
+
+// UserFromContext returns the user ID set by ContextWithUser
+func UserFromContext(ctx context.Context) (string, bool) {
+	return errors.UserFromContext(ctx)
+}
+
+// TagsFromContext returns the tags set by ContextWithTags. The map must not
+// be modified.
+func TagsFromContext(ctx context.Context) map[string]interface{} {
+	return errors.TagsFromContext(ctx)
+}
+
+// WithContextExtractors registers functions that fill reports from values
+// your application keeps in context.Context, such as a request ID set by
+// another middleware. They run in order after options are applied, so they
+// should only fill fields that are still empty.
+func WithContextExtractors(extractors ...ContextExtractor) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.Extractors = append(c.Extractors, extractors...)
+	}
+}
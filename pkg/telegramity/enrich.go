@@ -0,0 +1,47 @@
+package telegramity
+
+import (
+	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/enrich"
+	"github.com/somosbytes/telegramity/internal/errors"
+)
+
+// Types needed to write a custom enricher or processor
+type (
+	Enricher     = configs.Enricher
+	RuntimeField = errors.RuntimeField
+	Processor    = configs.Processor
+)
+
+// Built-in enrichers, for use with WithEnrichers
+var (
+	EnrichHost       = enrich.Host
+	EnrichGo         = enrich.Go
+	EnrichBuild      = enrich.Build
+	EnrichGoroutines = enrich.Goroutines
+	EnrichMemory     = enrich.Memory
+)
+
+// WithRuntimeInfo adds a Runtime section to every report with the
+// hostname and PID, Go version and platform, module version and VCS
+// revision, goroutine count and memory usage
+func WithRuntimeInfo() configs.ConfigOption {
+	return WithEnrichers(enrich.All()...)
+}
+
+// WithEnrichers runs the given enrichers on every report that is sent,
+// after deduplication and in the order given
+func WithEnrichers(enrichers ...Enricher) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.Enrichers = append(c.Enrichers, enrichers...)
+	}
+}
+
+// WithProcessors runs the given processors on every report before it is
+// deduplicated and formatted, in the order given. A processor can modify
+// the report, replace it, or drop it by returning nil or false.
+func WithProcessors(processors ...Processor) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.Processors = append(c.Processors, processors...)
+	}
+}
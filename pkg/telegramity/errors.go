@@ -1,6 +1,10 @@
 package telegramity
 
-import "github.com/somosbytes/telegramity/internal/errors"
+import (
+	"time"
+
+	"github.com/somosbytes/telegramity/internal/errors"
+)
 
 const (
 	SeverityLow      errors.Severity = errors.SeverityLow      // Minor issues, informational
@@ -18,4 +22,94 @@ const (
 	ErrorTypeInternal   = errors.ErrorTypeInternal
 	ErrorTypeRateLimit  = errors.ErrorTypeRateLimit
 	ErrorTypeTimeout    = errors.ErrorTypeTimeout
+	ErrorTypePanic      = errors.ErrorTypePanic
+	ErrorTypeHTTP       = errors.ErrorTypeHTTP
+	ErrorTypeLog        = errors.ErrorTypeLog
 )
+
+func WithSeverity(severity errors.Severity) errors.ErrorOption {
+	return func(r *errors.ErrorReport) {
+		r.Severity = severity
+	}
+}
+
+func WithUserID(userID string) errors.ErrorOption {
+	return func(r *errors.ErrorReport) {
+		r.UserID = userID
+	}
+}
+
+func WithEnvironment(env string) errors.ErrorOption {
+	return func(r *errors.ErrorReport) {
+		r.Environment = env
+	}
+}
+
+func WithAppName(name string) errors.ErrorOption {
+	return func(r *errors.ErrorReport) {
+		r.AppName = name
+	}
+}
+
+// WithContext merges the given values into the report context,
+// overwriting existing keys.
+func WithContext(values map[string]interface{}) errors.ErrorOption {
+	return func(r *errors.ErrorReport) {
+		if r.Context == nil {
+			r.Context = make(map[string]interface{}, len(values))
+		}
+		for k, v := range values {
+			r.Context[k] = v
+		}
+	}
+}
+
+func WithContextValue(key string, value interface{}) errors.ErrorOption {
+	return func(r *errors.ErrorReport) {
+		if r.Context == nil {
+			r.Context = make(map[string]interface{})
+		}
+		r.Context[key] = value
+	}
+}
+
+// WithStackTrace replaces the automatically extracted stack trace.
+func WithStackTrace(stackTrace string) errors.ErrorOption {
+	return func(r *errors.ErrorReport) {
+		r.StackTrace = stackTrace
+	}
+}
+
+func WithTimestamp(t time.Time) errors.ErrorOption {
+	return func(r *errors.ErrorReport) {
+		r.Timestamp = t
+	}
+}
+
+func WithFingerprint(fingerprint string) errors.ErrorOption {
+	return func(r *errors.ErrorReport) {
+		r.Fingerprint = fingerprint
+	}
+}
+
+// WithTopic posts the report in the given forum topic of the default chat
+// instead of the one configured for its error type
+func WithTopic(topicID int) errors.ErrorOption {
+	return func(r *errors.ErrorReport) {
+		r.TopicID = topicID
+	}
+}
+
+// WithSilent sends the report without a notification sound
+func WithSilent() errors.ErrorOption {
+	return func(r *errors.ErrorReport) {
+		r.Silent = true
+	}
+}
+
+// WithProtectedContent prevents the report from being forwarded or saved
+func WithProtectedContent() errors.ErrorOption {
+	return func(r *errors.ErrorReport) {
+		r.Protected = true
+	}
+}
@@ -0,0 +1,74 @@
+package telegramity
+
+import (
+	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/dedup"
+	"github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/internal/formatters"
+)
+
+// Types needed to implement a custom Formatter
+type (
+	Formatter   = configs.Formatter
+	Message     = configs.Message
+	ErrorReport = errors.ErrorReport
+	Summary     = dedup.Summary
+)
+
+// Parse modes for Message
+const (
+	ParseModeNone       = configs.ParseModeNone
+	ParseModeHTML       = configs.ParseModeHTML
+	ParseModeMarkdownV2 = configs.ParseModeMarkdownV2
+)
+
+// WithFormatter replaces the built-in HTML layout with a custom formatter.
+// Messages it returns are sent as they are, so they must fit within
+// Telegram's 4096 character limit.
+func WithFormatter(formatter Formatter) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.Formatter = formatter
+	}
+}
+
+// WithMarkdownV2Formatter sends reports using the built-in layout in
+// Telegram's MarkdownV2 parse mode
+func WithMarkdownV2Formatter() configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.Formatter = formatters.NewMarkdownV2Formatter(c)
+	}
+}
+
+// WithPlainTextFormatter sends reports using the built-in layout without
+// any formatting
+func WithPlainTextFormatter() configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.Formatter = formatters.NewPlainTextFormatter(c)
+	}
+}
+
+// WithTemplate formats reports of the given error type with an HTML
+// text/template, or reports of every type without their own template if
+// errorType is empty. The template is executed with the *ErrorReport and
+// may use the escape, truncate, code, pre and since helpers. Templates are
+// checked by NewClient.
+func WithTemplate(errorType, text string) configs.ConfigOption {
+	return func(c *configs.Config) {
+		setTemplate(c, errorType, configs.MessageTemplate{Text: text})
+	}
+}
+
+// WithTemplateFile is like WithTemplate, reading the template from a file
+// when the client is created
+func WithTemplateFile(errorType, path string) configs.ConfigOption {
+	return func(c *configs.Config) {
+		setTemplate(c, errorType, configs.MessageTemplate{File: path})
+	}
+}
+
+func setTemplate(c *configs.Config, errorType string, source configs.MessageTemplate) {
+	if c.Templates == nil {
+		c.Templates = make(map[string]configs.MessageTemplate)
+	}
+	c.Templates[errorType] = source
+}
@@ -0,0 +1,272 @@
+package telegramity
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/internal/telegram/bot"
+)
+
+// StatusRange is an inclusive range of HTTP status codes
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// HTTPOptions configures HTTPMiddleware. The zero value reports 5xx
+// responses and panics for every path.
+type HTTPOptions struct {
+	StatusRanges      []StatusRange                // Status codes to report (default 500-599)
+	ExcludePaths      []string                     // Paths to skip; a trailing "*" matches by prefix
+	RequestIDHeader   string                       // Header carrying the request ID (default X-Request-ID)
+	RedactQueryParams []string                     // Query parameters to redact (default: common secrets)
+	TrustProxyHeaders bool                         // Take the remote IP from X-Forwarded-For / X-Real-IP
+	RouteFunc         func(r *http.Request) string // Returns the matched route (default r.Pattern)
+	ErrorType         string                       // Error type for reports (default ErrorTypeHTTP)
+}
+
+var defaultRedactQueryParams = []string{
+	"token", "access_token", "refresh_token", "api_key", "apikey", "key",
+	"password", "passwd", "secret", "signature", "auth",
+}
+
+// HTTPMiddleware returns middleware that reports handler panics and
+// responses whose status falls within opts.StatusRanges. Reports are sent
+// before the handler returns, so an async client is recommended.
+func HTTPMiddleware(client bot.Client, opts HTTPOptions) func(http.Handler) http.Handler {
+	if len(opts.StatusRanges) == 0 {
+		opts.StatusRanges = []StatusRange{{Min: 500, Max: 599}}
+	}
+	if opts.RequestIDHeader == "" {
+		opts.RequestIDHeader = "X-Request-ID"
+	}
+	if opts.RedactQueryParams == nil {
+		opts.RedactQueryParams = defaultRedactQueryParams
+	}
+	if opts.RouteFunc == nil {
+		opts.RouteFunc = func(r *http.Request) string { return r.Pattern }
+	}
+	if opts.ErrorType == "" {
+		opts.ErrorType = ErrorTypeHTTP
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.excluded(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+
+			defer func() {
+				v := recover()
+				if v == nil {
+					// A hijacked connection, such as a WebSocket, has no status
+					if !rec.hijacked && opts.reportable(rec.status()) {
+						err := fmt.Errorf("%s %s returned %d %s", r.Method, r.URL.Path, rec.status(), http.StatusText(rec.status()))
+						opts.report(client, r, rec, start, err, WithSeverity(SeverityHigh))
+					}
+					return
+				}
+
+				// Let net/http handle deliberate aborts
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				if !rec.wroteHeader && !rec.hijacked {
+					http.Error(rec, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+				opts.report(client, r, rec, start, &errors.PanicError{Value: v},
+					WithSeverity(SeverityCritical),
+					WithStackTrace(errors.PanicStackTrace(debug.Stack())),
+				)
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+func (o HTTPOptions) report(client bot.Client, r *http.Request, rec *statusRecorder, start time.Time, err error, opts ...errors.ErrorOption) {
+	values := map[string]interface{}{
+		"method":     r.Method,
+		"path":       r.URL.Path,
+		"remote_ip":  o.remoteIP(r),
+		"user_agent": r.UserAgent(),
+		"latency":    time.Since(start).Round(time.Millisecond).String(),
+	}
+	if rec.hijacked {
+		values["hijacked"] = true
+	} else {
+		values["status"] = rec.status()
+	}
+	if route := o.RouteFunc(r); route != "" {
+		values["route"] = route
+	}
+	if r.URL.RawQuery != "" {
+		values["query"] = o.redactQuery(r.URL.Query())
+	}
+	if requestID := r.Header.Get(o.RequestIDHeader); requestID != "" {
+		values["request_id"] = requestID
+	}
+
+	// The response is done, so the report must outlive the request context
+	ctx := context.WithoutCancel(r.Context())
+	_ = client.ReportError(ctx, err, o.ErrorType, append([]errors.ErrorOption{WithContext(values)}, opts...)...)
+}
+
+func (o HTTPOptions) excluded(path string) bool {
+	for _, pattern := range o.ExcludePaths {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func (o HTTPOptions) reportable(status int) bool {
+	for _, sr := range o.StatusRanges {
+		if status >= sr.Min && status <= sr.Max {
+			return true
+		}
+	}
+	return false
+}
+
+func (o HTTPOptions) remoteIP(r *http.Request) string {
+	if o.TrustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			return realIP
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// redactQuery encodes the query with sorted keys, replacing the values of
+// sensitive parameters.
+func (o HTTPOptions) redactQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		redact := false
+		for _, name := range o.RedactQueryParams {
+			if strings.EqualFold(k, name) {
+				redact = true
+				break
+			}
+		}
+
+		for _, v := range query[k] {
+			if redact {
+				v = "[REDACTED]"
+			} else {
+				v = url.QueryEscape(v)
+			}
+			parts = append(parts, url.QueryEscape(k)+"="+v)
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+	hijacked    bool
+}
+
+// WriteHeader records the final status. Informational codes such as 103
+// Early Hints precede it, except for 101 Switching Protocols.
+func (w *statusRecorder) WriteHeader(code int) {
+	if !w.wroteHeader && (code >= 200 || code == http.StatusSwitchingProtocols) {
+		w.code = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Hijack lets handlers take over the connection, e.g. to upgrade it to a
+// WebSocket, when the underlying writer supports it
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// ReadFrom keeps the underlying writer's io.ReaderFrom, which net/http
+// uses to send files efficiently
+func (w *statusRecorder) ReadFrom(r io.Reader) (int64, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(w.ResponseWriter, r)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *statusRecorder) status() int {
+	if !w.wroteHeader {
+		return http.StatusOK
+	}
+	return w.code
+}
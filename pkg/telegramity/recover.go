@@ -0,0 +1,63 @@
+package telegramity
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/somosbytes/telegramity/internal/errors"
+)
+
+// repanicFlushTimeout bounds how long RecoverAndRepanic waits for the
+// report to be delivered before crashing the program
+const repanicFlushTimeout = 5 * time.Second
+
+// Recover reports a panic to the global client and stops it from
+// propagating. It must be deferred directly:
+//
+//	defer telegramity.Recover(ctx)
+//
+// The panic is still swallowed when the global client is not initialized.
+func Recover(ctx context.Context, opts ...errors.ErrorOption) {
+	if r := recover(); r != nil {
+		reportPanic(ctx, r, debug.Stack(), opts)
+	}
+}
+
+// RecoverAndRepanic reports a panic to the global client, waits for the
+// report to be delivered and then panics again with the same value. It must
+// be deferred directly.
+func RecoverAndRepanic(ctx context.Context, opts ...errors.ErrorOption) {
+	if r := recover(); r != nil {
+		reportPanic(ctx, r, debug.Stack(), opts)
+
+		if client := loadGlobalClient(); client != nil {
+			flushCtx, cancel := context.WithTimeout(context.Background(), repanicFlushTimeout)
+			_ = client.Flush(flushCtx)
+			cancel()
+		}
+		panic(r)
+	}
+}
+
+// Go runs fn in a new goroutine, reporting and recovering any panic.
+func Go(fn func(), opts ...errors.ErrorOption) {
+	go func() {
+		defer Recover(context.Background(), opts...)
+		fn()
+	}()
+}
+
+func reportPanic(ctx context.Context, value interface{}, stack []byte, opts []errors.ErrorOption) {
+	client := loadGlobalClient()
+	if client == nil {
+		return
+	}
+
+	panicOpts := []errors.ErrorOption{
+		WithSeverity(SeverityCritical),
+		WithStackTrace(errors.PanicStackTrace(stack)),
+	}
+
+	_ = client.ReportError(ctx, &errors.PanicError{Value: value}, ErrorTypePanic, append(panicOpts, opts...)...)
+}
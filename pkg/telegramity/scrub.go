@@ -0,0 +1,38 @@
+package telegramity
+
+import (
+	"regexp"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/scrub"
+)
+
+// Redacted replaces every value removed by scrubbing
+const Redacted = scrub.Redacted
+
+// WithScrubbing redacts credentials and personal data from error text,
+// stack traces and context before reports are sent: values of context
+// keys such as password, secret, token and authorization, bearer tokens,
+// passwords in DSNs and URLs, card numbers, email addresses and the bot
+// token itself
+func WithScrubbing() configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.Scrub = true
+	}
+}
+
+// WithScrubKeys redacts the values of context keys containing any of the
+// given names, ignoring case, at any nesting level
+func WithScrubKeys(names ...string) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.ScrubKeys = append(c.ScrubKeys, names...)
+	}
+}
+
+// WithScrubPatterns redacts every match of the given patterns from error
+// text, stack traces and context values
+func WithScrubPatterns(patterns ...*regexp.Regexp) configs.ConfigOption {
+	return func(c *configs.Config) {
+		c.ScrubPatterns = append(c.ScrubPatterns, patterns...)
+	}
+}
@@ -1,6 +1,7 @@
 package telegramity
 
 import (
+	"context"
 	"sync"
 
 	"github.com/somosbytes/telegramity/internal/configs"
@@ -8,12 +9,24 @@ import (
 	"github.com/somosbytes/telegramity/internal/telegramity"
 )
 
+// AbandonedError is returned by Shutdown and Flush when reports are still
+// pending at the deadline
+type AbandonedError = bot.AbandonedError
+
 var (
+	globalMu     sync.RWMutex
 	globalClient bot.Client
 	globalOnce   sync.Once
 	globalErr    error
 )
 
+// loadGlobalClient returns the global client, or nil if there is none
+func loadGlobalClient() bot.Client {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return globalClient
+}
+
 func InitGlobalClient(botToken string, chatID int64, options ...configs.ConfigOption) error {
 	globalOnce.Do(func() {
 		client, err := telegramity.NewClient(botToken, chatID, options...)
@@ -21,21 +34,65 @@ func InitGlobalClient(botToken string, chatID int64, options ...configs.ConfigOp
 			globalErr = err
 			return
 		}
-		globalClient = client
+		SetGlobalClient(client)
+	})
+	return globalErr
+}
+
+// InitGlobalClientForChat is InitGlobalClient for a chat given by numeric
+// ID or by username, such as ChatUsername("@alerts")
+func InitGlobalClientForChat(botToken string, chat ChatTarget, options ...configs.ConfigOption) error {
+	globalOnce.Do(func() {
+		client, err := telegramity.NewClientForChat(botToken, chat, options...)
+		if err != nil {
+			globalErr = err
+			return
+		}
+		SetGlobalClient(client)
 	})
 	return globalErr
 }
 
+// SetGlobalClient installs client as the global client, replacing any
+// set before, e.g. one built on a stub BotClient in tests. A nil client
+// removes it.
+func SetGlobalClient(client bot.Client) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	globalClient = client
+}
+
 func GetGlobalClient() bot.Client {
-	if globalClient == nil {
+	client := loadGlobalClient()
+	if client == nil {
 		panic("telegramity: global client not initialized. Call InitGlobalClient first")
 	}
-	return globalClient
+	return client
 }
 
+// CloseGlobalClient shuts the global client down, waiting up to the
+// configured shutdown timeout for pending reports to be delivered.
 func CloseGlobalClient() error {
-	if globalClient != nil {
-		return globalClient.Close()
+	if client := loadGlobalClient(); client != nil {
+		return client.Close()
+	}
+	return nil
+}
+
+// ShutdownGlobalClient shuts the global client down, waiting for pending
+// reports until ctx is done.
+func ShutdownGlobalClient(ctx context.Context) error {
+	if client := loadGlobalClient(); client != nil {
+		return client.Shutdown(ctx)
+	}
+	return nil
+}
+
+// FlushGlobalClient waits for pending reports of the global client to be
+// delivered until ctx is done.
+func FlushGlobalClient(ctx context.Context) error {
+	if client := loadGlobalClient(); client != nil {
+		return client.Flush(ctx)
 	}
 	return nil
 }
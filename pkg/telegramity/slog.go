@@ -0,0 +1,190 @@
+package telegramity
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+
+	"github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/internal/telegram/bot"
+)
+
+// SlogHandlerOptions configures SlogHandler
+type SlogHandlerOptions struct {
+	Level     slog.Leveler // Minimum level that is reported (default slog.LevelError)
+	ErrorType string       // Error type for reports (default ErrorTypeLog)
+}
+
+// SlogHandler is a slog.Handler that passes every record to the wrapped
+// handler and reports records at or above the configured level.
+type SlogHandler struct {
+	client bot.Client
+	next   slog.Handler
+	opts   SlogHandlerOptions
+
+	prefix string                 // Group path for attributes added from now on
+	attrs  map[string]interface{} // Attributes added with WithAttrs, already flattened
+	err    error                  // First error-valued attribute added with WithAttrs
+	errKey string                 // Key of err in attrs
+}
+
+// NewSlogHandler wraps next so that error-level records are also sent to
+// Telegram. A nil next discards records after reporting them. Reports are
+// sent before Handle returns, so an async client is recommended.
+func NewSlogHandler(client bot.Client, next slog.Handler, opts SlogHandlerOptions) *SlogHandler {
+	if next == nil {
+		next = slog.DiscardHandler
+	}
+	if opts.Level == nil {
+		opts.Level = slog.LevelError
+	}
+	if opts.ErrorType == "" {
+		opts.ErrorType = ErrorTypeLog
+	}
+
+	return &SlogHandler{
+		client: client,
+		next:   next,
+		opts:   opts,
+		attrs:  make(map[string]interface{}),
+	}
+}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.opts.Level.Level() || h.next.Enabled(ctx, level)
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	var err error
+	if h.next.Enabled(ctx, record.Level) {
+		err = h.next.Handle(ctx, record)
+	}
+
+	if record.Level >= h.opts.Level.Level() {
+		h.report(ctx, record)
+	}
+
+	return err
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	clone := h.clone()
+	clone.next = h.next.WithAttrs(attrs)
+	for _, attr := range attrs {
+		if attrErr, ok := attr.Value.Resolve().Any().(error); ok && clone.err == nil {
+			clone.err, clone.errKey = attrErr, clone.prefix+attr.Key
+		}
+		flattenAttr(clone.attrs, clone.prefix, attr)
+	}
+	return clone
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	clone := h.clone()
+	clone.next = h.next.WithGroup(name)
+	clone.prefix = h.prefix + name + "."
+	return clone
+}
+
+func (h *SlogHandler) clone() *SlogHandler {
+	attrs := make(map[string]interface{}, len(h.attrs))
+	for k, v := range h.attrs {
+		attrs[k] = v
+	}
+
+	return &SlogHandler{
+		client: h.client,
+		next:   h.next,
+		opts:   h.opts,
+		prefix: h.prefix,
+		attrs:  attrs,
+		err:    h.err,
+		errKey: h.errKey,
+	}
+}
+
+func (h *SlogHandler) report(ctx context.Context, record slog.Record) {
+	values := make(map[string]interface{}, len(h.attrs)+record.NumAttrs())
+	for k, v := range h.attrs {
+		values[k] = v
+	}
+
+	// The first error-valued record attribute becomes the reported error,
+	// or else the first one added with WithAttrs
+	var err error
+	record.Attrs(func(attr slog.Attr) bool {
+		if attrErr, ok := attr.Value.Resolve().Any().(error); ok && err == nil {
+			err = fmt.Errorf("%s: %w", record.Message, attrErr)
+			return true
+		}
+		flattenAttr(values, h.prefix, attr)
+		return true
+	})
+	if err == nil && h.err != nil {
+		err = fmt.Errorf("%s: %w", record.Message, h.err)
+		delete(values, h.errKey)
+	}
+	if err == nil {
+		err = fmt.Errorf("%s", record.Message)
+	}
+
+	opts := []errors.ErrorOption{
+		WithSeverity(slogSeverity(record.Level)),
+		WithContext(values),
+	}
+	// Handlers must ignore a zero time; the report then gets the current one
+	if !record.Time.IsZero() {
+		opts = append(opts, WithTimestamp(record.Time))
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		opts = append(opts, WithStackTrace(fmt.Sprintf("%s\n\t%s:%d", frame.Function, frame.File, frame.Line)))
+	}
+
+	_ = h.client.ReportError(ctx, err, h.opts.ErrorType, opts...)
+}
+
+// flattenAttr adds attr to values, joining group names into dotted keys
+func flattenAttr(values map[string]interface{}, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		// A group with an empty key is inlined into the current group
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			flattenAttr(values, groupPrefix, groupAttr)
+		}
+		return
+	}
+
+	values[prefix+attr.Key] = attr.Value.Any()
+}
+
+// slogSeverity maps a log level to a report severity
+func slogSeverity(level slog.Level) errors.Severity {
+	switch {
+	case level > slog.LevelError:
+		return SeverityCritical
+	case level >= slog.LevelError:
+		return SeverityHigh
+	case level >= slog.LevelWarn:
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
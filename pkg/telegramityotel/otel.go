@@ -0,0 +1,80 @@
+// Package telegramityotel correlates Telegramity reports with OpenTelemetry
+// traces. It is a module of its own so that applications that do not use
+// OpenTelemetry do not depend on it.
+package telegramityotel
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+// Option customizes trace correlation
+type Option func(*options)
+
+type options struct {
+	urlTemplate  string
+	recordErrors bool
+}
+
+// WithTraceURL links the trace ID in reports to a tracing UI. The template
+// may contain {trace_id} and {span_id}, e.g.
+// "https://jaeger.example.com/trace/{trace_id}".
+func WithTraceURL(template string) Option {
+	return func(o *options) {
+		o.urlTemplate = template
+	}
+}
+
+// WithSpanEvents records every reported error on the active span as an
+// exception event
+func WithSpanEvents(record bool) Option {
+	return func(o *options) {
+		o.recordErrors = record
+	}
+}
+
+// WithTracing adds the trace and span IDs of the span carried by the
+// context passed to ReportError to every report
+func WithTracing(opts ...Option) telegramity.ConfigOption {
+	return telegramity.WithContextExtractors(Extractor(opts...))
+}
+
+// Extractor returns the ContextExtractor used by WithTracing, for
+// combining with other extractors
+func Extractor(opts ...Option) telegramity.ContextExtractor {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(ctx context.Context, report *telegramity.ErrorReport) {
+		span := trace.SpanFromContext(ctx)
+		sc := span.SpanContext()
+		if !sc.IsValid() {
+			return
+		}
+
+		if report.TraceID == "" {
+			report.TraceID = sc.TraceID().String()
+			report.SpanID = sc.SpanID().String()
+		}
+		if report.TraceURL == "" && o.urlTemplate != "" {
+			report.TraceURL = strings.NewReplacer(
+				"{trace_id}", report.TraceID,
+				"{span_id}", report.SpanID,
+			).Replace(o.urlTemplate)
+		}
+
+		if o.recordErrors && span.IsRecording() {
+			span.RecordError(report.Error, trace.WithAttributes(
+				attribute.String("telegramity.error_type", report.ErrorType),
+				attribute.String("telegramity.severity", string(report.Severity)),
+			))
+		}
+	}
+}
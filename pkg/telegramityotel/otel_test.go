@@ -0,0 +1,68 @@
+package telegramityotel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+	"github.com/somosbytes/telegramity/pkg/telegramityotel"
+)
+
+func TestExtractor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "checkout")
+
+	extract := telegramityotel.Extractor(
+		telegramityotel.WithTraceURL("https://tempo.example.com/trace/{trace_id}?span={span_id}"),
+		telegramityotel.WithSpanEvents(true),
+	)
+	report := newReport()
+	extract(ctx, report)
+	span.End()
+
+	traceID := span.SpanContext().TraceID().String()
+	spanID := span.SpanContext().SpanID().String()
+	if report.TraceID != traceID || report.SpanID != spanID {
+		t.Errorf("Expected trace %s and span %s, got %s and %s", traceID, spanID, report.TraceID, report.SpanID)
+	}
+	if want := "https://tempo.example.com/trace/" + traceID + "?span=" + spanID; report.TraceURL != want {
+		t.Errorf("Expected trace URL %q, got %q", want, report.TraceURL)
+	}
+
+	ended := recorder.Ended()
+	if len(ended) != 1 || len(ended[0].Events()) != 1 || ended[0].Events()[0].Name != "exception" {
+		t.Fatalf("Expected one exception event on the span, got %+v", ended)
+	}
+}
+
+func TestExtractorWithoutSpan(t *testing.T) {
+	report := newReport()
+	telegramityotel.Extractor(telegramityotel.WithTraceURL("https://ui/{trace_id}"))(context.Background(), report)
+
+	if report.TraceID != "" || report.SpanID != "" || report.TraceURL != "" {
+		t.Errorf("Expected no trace fields, got %q %q %q", report.TraceID, report.SpanID, report.TraceURL)
+	}
+}
+
+func TestExtractorKeepsExistingTrace(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "checkout")
+	defer span.End()
+
+	report := newReport()
+	report.TraceID, report.SpanID = "upstream", "span"
+	telegramityotel.Extractor(telegramityotel.WithTraceURL("https://ui/{trace_id}"))(ctx, report)
+
+	if report.TraceID != "upstream" || report.TraceURL != "https://ui/upstream" {
+		t.Errorf("Expected the existing trace to be kept, got %q %q", report.TraceID, report.TraceURL)
+	}
+}
+
+func newReport() *telegramity.ErrorReport {
+	return &telegramity.ErrorReport{Error: errors.New("boom"), ErrorType: "test_type"}
+}
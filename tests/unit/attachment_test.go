@@ -0,0 +1,172 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	internalerrors "github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/internal/formatters"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+func TestReportAttachment(t *testing.T) {
+	tests := []struct {
+		name       string
+		severity   internalerrors.Severity
+		stackTrace string
+		opts       []internalerrors.ErrorOption
+		want       bool
+	}{
+		{
+			name:       "short_report",
+			stackTrace: longStackTrace(2),
+			want:       false,
+		},
+		{
+			name:       "stack_frames_cut",
+			stackTrace: longStackTrace(15),
+			want:       true,
+		},
+		{
+			name:       "message_truncated",
+			stackTrace: longStackTrace(2),
+			opts:       []internalerrors.ErrorOption{telegramity.WithContext(largeContext(20))},
+			want:       true,
+		},
+		{
+			name:       "context_value_cut",
+			stackTrace: longStackTrace(2),
+			opts:       []internalerrors.ErrorOption{telegramity.WithContextValue("payload", strings.Repeat("x", 300))},
+			want:       true,
+		},
+		{
+			name:       "context_collapsed",
+			stackTrace: longStackTrace(2),
+			opts: []internalerrors.ErrorOption{telegramity.WithContextValue("request", map[string]interface{}{
+				"body": map[string]interface{}{"items": []int{1, 2}},
+			})},
+			want: true,
+		},
+		{
+			name:       "severe_report",
+			severity:   telegramity.SeverityHigh,
+			stackTrace: longStackTrace(2),
+			opts:       []internalerrors.ErrorOption{telegramity.WithSeverity(telegramity.SeverityCritical)},
+			want:       true,
+		},
+		{
+			name:       "below_attach_severity",
+			severity:   telegramity.SeverityHigh,
+			stackTrace: longStackTrace(2),
+			opts:       []internalerrors.ErrorOption{telegramity.WithSeverity(telegramity.SeverityMedium)},
+			want:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			botClient := &recordingBotClient{}
+			client := newTestClient(t, botClient,
+				telegramity.WithReportAttachment(telegramity.AttachText, tt.severity),
+				func(c *configs.Config) { c.MaxMessageLength = 1200 },
+			)
+
+			opts := append([]internalerrors.ErrorOption{telegramity.WithStackTrace(tt.stackTrace)}, tt.opts...)
+			if err := client.ReportError(context.Background(), errors.New("boom"), "db/query", opts...); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			documents := botClient.sentDocuments()
+			if !tt.want {
+				if len(documents) != 0 {
+					t.Errorf("Expected no document, got %d", len(documents))
+				}
+				return
+			}
+
+			if len(documents) != 1 {
+				t.Fatalf("Expected 1 document, got %d", len(documents))
+			}
+			doc := documents[0]
+			if doc.opts.ReplyToMessageID != 1 || doc.chatID != 123456789 {
+				t.Errorf("Expected a reply to message 1 in the report chat, got %+v", doc.opts)
+			}
+			if !strings.HasPrefix(doc.name, "report-db_query-") || !strings.HasSuffix(doc.name, ".txt") {
+				t.Errorf("Unexpected document name %q", doc.name)
+			}
+			if !strings.Contains(string(doc.data), "main.handler1(&amp;state)") {
+				t.Errorf("Expected the full stack trace in the document, got:\n%s", doc.data)
+			}
+			if tt.stackTrace == longStackTrace(15) && !strings.Contains(string(doc.data), "main.handler14") {
+				t.Errorf("Expected every stack frame in the document")
+			}
+		})
+	}
+}
+
+func TestReportDocumentJSON(t *testing.T) {
+	report := internalerrors.CreateErrorReport(errors.New("boom"), "payment",
+		telegramity.WithSeverity(telegramity.SeverityCritical),
+		telegramity.WithTimestamp(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)),
+		telegramity.WithStackTrace("main.main()\n\t/app/main.go:10"),
+		telegramity.WithContext(map[string]interface{}{
+			"cause":  errors.New("card declined"),
+			"amount": 42,
+			"ch":     make(chan int),
+		}),
+	)
+	report.Runtime = []telegramity.RuntimeField{{Name: "host", Value: "web-1"}}
+
+	name, data, err := formatters.ReportDocument(report, configs.AttachJSON)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name != "report-payment-20260102-030405.json" {
+		t.Errorf("Unexpected document name %q", name)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, data)
+	}
+
+	for key, want := range map[string]string{
+		"error":       "boom",
+		"severity":    "critical",
+		"stack_trace": "main.main()\n\t/app/main.go:10",
+		"context":     "map[amount:42 cause:card declined ch:" + fmt.Sprint(report.Context["ch"]) + "]",
+		"runtime":     "map[host:web-1]",
+	} {
+		if got := fmt.Sprint(doc[key]); got != want {
+			t.Errorf("Expected %s %q, got %q", key, want, got)
+		}
+	}
+}
+
+func TestAttachmentFormatValidation(t *testing.T) {
+	config := configs.DefaultConfig()
+	config.BotToken = "test_token"
+	config.Chat = configs.ChatID(123456789)
+	telegramity.WithReportAttachment("pdf", "")(&config)
+
+	err := config.Validate()
+	if err == nil || !strings.Contains(err.Error(), "unknown attachment format") {
+		t.Errorf("Expected an unknown attachment format error, got %v", err)
+	}
+}
+
+// largeContext returns n context values that together overflow a short
+// message
+func largeContext(n int) map[string]interface{} {
+	context := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		context[fmt.Sprintf("field%02d", i)] = strings.Repeat("value ", 30)
+	}
+	return context
+}
@@ -14,6 +14,8 @@ type MockBotClient struct {
 	shouldFail  bool
 	lastMessage string
 	lastChatID  int64
+	chats       map[string]int64 // Chat IDs by username
+	resolved    int              // Number of ResolveChat calls
 }
 
 // SendMessage is a mock implementation of the SendMessage method
@@ -41,6 +43,33 @@ func (m *MockBotClient) SendMessage(ctx context.Context, chatID int64, message s
 	return nil
 }
 
+// SendMessageWithOptions is a mock implementation of the SendMessageWithOptions method
+func (m *MockBotClient) SendMessageWithOptions(ctx context.Context, chatID int64, message string, opts bot.SendOptions) (int, error) {
+	if err := m.SendMessage(ctx, chatID, message); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// SendDocument is a mock implementation of the SendDocument method
+func (m *MockBotClient) SendDocument(ctx context.Context, chatID int64, name string, data []byte, opts bot.SendOptions) (int, error) {
+	if err := m.SendMessage(ctx, chatID, string(data)); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// ResolveChat is a mock implementation of the ResolveChat method
+func (m *MockBotClient) ResolveChat(ctx context.Context, username string) (int64, error) {
+	m.resolved++
+
+	chatID, ok := m.chats[username]
+	if !ok || m.shouldFail {
+		return 0, &bot.SendError{Kind: bot.KindBadRequest, Code: 400, Description: "Bad Request: chat not found"}
+	}
+	return chatID, nil
+}
+
 // TestConnection is a mock implementation of the TestConnection method
 func (m *MockBotClient) TestConnection(ctx context.Context) error {
 	select {
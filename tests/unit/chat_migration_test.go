@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/somosbytes/telegramity/internal/telegram/bot"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+func TestChatMigration(t *testing.T) {
+	const oldChatID, newChatID = 123456789, -1001234567890
+
+	migrated := &bot.SendError{
+		Kind:            bot.KindBadRequest,
+		Code:            400,
+		Description:     "Bad Request: group chat was upgraded to a supergroup chat",
+		MigrateToChatID: newChatID,
+	}
+	botClient := &recordingBotClient{chatErrs: map[int64]error{oldChatID: migrated}}
+
+	var mu sync.Mutex
+	var calls []string
+	client := newTestClient(t, botClient,
+		telegramity.WithAsync(4, 10),
+		telegramity.WithChatMigrationHandler(func(oldID, newID int64) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, fmt.Sprintf("%d->%d", oldID, newID))
+		}),
+	)
+
+	for i := 0; i < 5; i++ {
+		if err := client.ReportError(context.Background(), fmt.Errorf("boom %d", i), "test_type"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := client.Flush(context.Background()); err != nil {
+		t.Fatalf("Unexpected flush error: %v", err)
+	}
+
+	sentTo := botClient.sentTo()
+	if len(sentTo) != 5 {
+		t.Fatalf("Expected 5 messages, got %d", len(sentTo))
+	}
+	for _, chatID := range sentTo {
+		if chatID != newChatID {
+			t.Errorf("Expected messages in the new chat, got %d", chatID)
+		}
+	}
+	if stats := client.Stats(); stats.Failed != 0 {
+		t.Errorf("Expected no failed reports, got %d", stats.Failed)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 1 || calls[0] != fmt.Sprintf("%d->%d", oldChatID, newChatID) {
+		t.Errorf("Expected one migration callback, got %v", calls)
+	}
+}
+
+func TestMigrateToChatID(t *testing.T) {
+	err := bot.ClassifyError(&tgbotapi.Error{
+		Code:               400,
+		Message:            "Bad Request: group chat was upgraded to a supergroup chat",
+		ResponseParameters: tgbotapi.ResponseParameters{MigrateToChatID: -1001234567890},
+	})
+	if got := bot.MigrateToChatID(fmt.Errorf("failed to send message: %w", err)); got != -1001234567890 {
+		t.Errorf("Expected the new chat ID, got %d", got)
+	}
+	if got := bot.MigrateToChatID(errors.New("other")); got != 0 {
+		t.Errorf("Expected 0 for other errors, got %d", got)
+	}
+}
@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/telegram/bot"
+	internaltelegramity "github.com/somosbytes/telegramity/internal/telegramity"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+func TestParseChatTarget(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    telegramity.ChatTarget
+		wantErr bool
+	}{
+		{input: "123456789", want: telegramity.ChatID(123456789)},
+		{input: "-1001234567890", want: telegramity.ChatID(-1001234567890)},
+		{input: "@alerts_channel", want: telegramity.ChatTarget{Username: "@alerts_channel"}},
+		{input: " @ops_alerts ", want: telegramity.ChatTarget{Username: "@ops_alerts"}},
+		{input: "0", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "alerts", wantErr: true},
+		{input: "@ab", wantErr: true},
+		{input: "@1alerts", wantErr: true},
+		{input: "@alerts-prod", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := telegramity.ParseChatTarget(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestChatTargetValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  telegramity.ChatTarget
+		wantErr bool
+	}{
+		{name: "id", target: telegramity.ChatID(42)},
+		{name: "username_without_at", target: telegramity.ChatUsername("alerts")},
+		{name: "empty", target: telegramity.ChatTarget{}, wantErr: true},
+		{name: "both", target: telegramity.ChatTarget{ID: 42, Username: "@alerts"}, wantErr: true},
+		{name: "invalid_username", target: telegramity.ChatUsername("a b c d"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.target.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := internaltelegramity.NewClient("test_token", 0); err == nil {
+		t.Error("Expected NewClient to reject a zero chat ID")
+	}
+}
+
+func TestResolveChat(t *testing.T) {
+	botClient := &MockBotClient{chats: map[string]int64{"@alerts": -1001234567890}}
+
+	config := configs.DefaultConfig()
+	config.Chat = telegramity.ChatUsername("@alerts")
+	if err := bot.ResolveChat(context.Background(), &config, botClient); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.ChatID != -1001234567890 || botClient.resolved != 1 {
+		t.Errorf("Expected the username to be resolved once, got ID %d after %d calls", config.ChatID, botClient.resolved)
+	}
+
+	config.Chat = telegramity.ChatUsername("@missing")
+	if err := bot.ResolveChat(context.Background(), &config, botClient); err == nil {
+		t.Error("Expected an error for an unknown chat")
+	}
+
+	config.Chat = telegramity.ChatID(42)
+	if err := bot.ResolveChat(context.Background(), &config, botClient); err != nil || config.ChatID != 42 {
+		t.Errorf("Expected a numeric ID to be used as is, got %d, %v", config.ChatID, err)
+	}
+	if botClient.resolved != 2 {
+		t.Errorf("Expected no lookup for a numeric ID, got %d calls", botClient.resolved)
+	}
+}
@@ -0,0 +1,459 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	internalerrors "github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/internal/ratelimit"
+	"github.com/somosbytes/telegramity/internal/telegram/bot"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+// sentDocument is a document uploaded through recordingBotClient
+type sentDocument struct {
+	chatID int64
+	name   string
+	data   []byte
+	opts   bot.SendOptions
+}
+
+// recordingBotClient is a concurrency-safe BotClient that records every
+// message and can hold sends until released
+type recordingBotClient struct {
+	mu         sync.Mutex
+	messages   []string
+	parseModes []string
+	chatIDs    []int64
+	options    []bot.SendOptions
+	documents  []sentDocument
+	release    chan struct{}
+	sendErr    error
+	chatErrs   map[int64]error // Errors returned for single chats
+}
+
+func (m *recordingBotClient) SendMessage(ctx context.Context, chatID int64, message string) error {
+	_, err := m.SendMessageWithOptions(ctx, chatID, message, bot.SendOptions{ParseMode: bot.ParseModeHTML})
+	return err
+}
+
+func (m *recordingBotClient) SendMessageWithOptions(ctx context.Context, chatID int64, message string, opts bot.SendOptions) (int, error) {
+	if m.release != nil {
+		select {
+		case <-m.release:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sendErr != nil {
+		return 0, m.sendErr
+	}
+	if err := m.chatErrs[chatID]; err != nil {
+		return 0, err
+	}
+	m.messages = append(m.messages, message)
+	m.parseModes = append(m.parseModes, opts.ParseMode)
+	m.chatIDs = append(m.chatIDs, chatID)
+	m.options = append(m.options, opts)
+	return len(m.messages), nil
+}
+
+func (m *recordingBotClient) SendDocument(ctx context.Context, chatID int64, name string, data []byte, opts bot.SendOptions) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sendErr != nil {
+		return 0, m.sendErr
+	}
+	m.documents = append(m.documents, sentDocument{chatID: chatID, name: name, data: data, opts: opts})
+	return len(m.messages) + len(m.documents), nil
+}
+
+func (m *recordingBotClient) sentDocuments() []sentDocument {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]sentDocument(nil), m.documents...)
+}
+
+func (m *recordingBotClient) ResolveChat(ctx context.Context, username string) (int64, error) {
+	return 0, errors.New("not supported")
+}
+
+func (m *recordingBotClient) TestConnection(ctx context.Context) error {
+	return nil
+}
+
+func (m *recordingBotClient) sentOptions() []bot.SendOptions {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]bot.SendOptions(nil), m.options...)
+}
+
+func (m *recordingBotClient) sentTo() []int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]int64(nil), m.chatIDs...)
+}
+
+func (m *recordingBotClient) sent() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.messages...)
+}
+
+func newTestClient(t *testing.T, botClient bot.BotClient, options ...configs.ConfigOption) bot.Client {
+	t.Helper()
+
+	config := configs.DefaultConfig()
+	config.ChatID = 123456789
+	config.RetryDelay = time.Millisecond
+	for _, option := range options {
+		option(&config)
+	}
+
+	client := bot.NewClient(&config, botClient, ratelimit.New(0, 1, 0))
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSyncDelivery(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient)
+
+	err := client.ReportError(context.Background(), errors.New("sync error"), "test_type")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := len(botClient.sent()); got != 1 {
+		t.Errorf("Expected 1 message, got %d", got)
+	}
+	if stats := client.Stats(); stats.Delivered != 1 {
+		t.Errorf("Expected 1 delivered report, got %d", stats.Delivered)
+	}
+}
+
+func TestAsyncDelivery(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient, telegramity.WithAsync(2, 10))
+
+	for i := 0; i < 5; i++ {
+		err := client.ReportError(context.Background(), errors.New("async error"), "test_type")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	waitFor(t, func() bool { return client.Stats().Delivered == 5 })
+
+	if got := len(botClient.sent()); got != 5 {
+		t.Errorf("Expected 5 messages, got %d", got)
+	}
+}
+
+func TestAsyncOverflowPolicies(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        configs.OverflowPolicy
+		expectErr     bool
+		expectDropped uint64
+	}{
+		{
+			name:          "drop_newest",
+			policy:        telegramity.OverflowDropNewest,
+			expectErr:     true,
+			expectDropped: 1,
+		},
+		{
+			name:          "drop_oldest",
+			policy:        telegramity.OverflowDropOldest,
+			expectErr:     false,
+			expectDropped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			botClient := &recordingBotClient{release: make(chan struct{})}
+			client := newTestClient(t, botClient,
+				telegramity.WithAsync(1, 1),
+				telegramity.WithOverflowPolicy(tt.policy),
+			)
+			ctx := context.Background()
+
+			// The first report occupies the worker, the second fills the queue
+			_ = client.ReportError(ctx, errors.New("first"), "test_type")
+			waitFor(t, func() bool { return client.Stats().Queued == 0 })
+			_ = client.ReportError(ctx, errors.New("second"), "test_type")
+
+			err := client.ReportError(ctx, errors.New("third"), "test_type")
+			if tt.expectErr && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			if stats := client.Stats(); stats.Dropped != tt.expectDropped {
+				t.Errorf("Expected %d dropped reports, got %d", tt.expectDropped, stats.Dropped)
+			}
+
+			close(botClient.release)
+		})
+	}
+}
+
+func TestAsyncOverflowBlock(t *testing.T) {
+	botClient := &recordingBotClient{release: make(chan struct{})}
+	client := newTestClient(t, botClient,
+		telegramity.WithAsync(1, 1),
+		telegramity.WithOverflowPolicy(telegramity.OverflowBlock),
+	)
+
+	_ = client.ReportError(context.Background(), errors.New("first"), "test_type")
+	waitFor(t, func() bool { return client.Stats().Queued == 0 })
+	_ = client.ReportError(context.Background(), errors.New("second"), "test_type")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := client.ReportError(ctx, errors.New("third"), "test_type")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	close(botClient.release)
+}
+
+func TestFlushWaitsForQueuedReports(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient, telegramity.WithAsync(1, 10))
+
+	for i := 0; i < 3; i++ {
+		_ = client.ReportError(context.Background(), errors.New("queued"), "test_type")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := client.Flush(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := len(botClient.sent()); got != 3 {
+		t.Errorf("Expected 3 messages after flush, got %d", got)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	t.Run("delivers_pending_reports", func(t *testing.T) {
+		botClient := &recordingBotClient{}
+		client := newTestClient(t, botClient, telegramity.WithAsync(1, 10))
+
+		for i := 0; i < 3; i++ {
+			_ = client.ReportError(context.Background(), errors.New("queued"), "test_type")
+		}
+
+		if err := client.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got := len(botClient.sent()); got != 3 {
+			t.Errorf("Expected 3 messages after shutdown, got %d", got)
+		}
+
+		err := client.ReportError(context.Background(), errors.New("late"), "test_type")
+		if err == nil {
+			t.Errorf("Expected error reporting on a closed client")
+		}
+	})
+
+	t.Run("reports_abandoned_on_deadline", func(t *testing.T) {
+		botClient := &recordingBotClient{release: make(chan struct{})}
+		client := newTestClient(t, botClient, telegramity.WithAsync(1, 10))
+
+		for i := 0; i < 3; i++ {
+			_ = client.ReportError(context.Background(), errors.New("stuck"), "test_type")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := client.Shutdown(ctx)
+
+		var abandoned *bot.AbandonedError
+		if !errors.As(err, &abandoned) {
+			t.Fatalf("Expected AbandonedError, got %v", err)
+		}
+		if abandoned.Abandoned != 3 {
+			t.Errorf("Expected 3 abandoned reports, got %d", abandoned.Abandoned)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected error to wrap context.DeadlineExceeded")
+		}
+		if stats := client.Stats(); stats.Abandoned != 3 {
+			t.Errorf("Expected 3 abandoned reports in stats, got %d", stats.Abandoned)
+		}
+	})
+
+	t.Run("aborts_sync_delivery_on_deadline", func(t *testing.T) {
+		botClient := &recordingBotClient{release: make(chan struct{})}
+		client := newTestClient(t, botClient)
+
+		result := make(chan error, 1)
+		go func() {
+			result <- client.ReportError(context.Background(), errors.New("stuck"), "test_type")
+		}()
+		waitFor(t, func() bool { return client.Flush(expiredContext()) != nil })
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		if err := client.Shutdown(ctx); err == nil {
+			t.Errorf("Expected error but got none")
+		}
+		if err := <-result; err == nil {
+			t.Errorf("Expected aborted delivery to return an error")
+		}
+	})
+}
+
+func expiredContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+// upperFormatter is a custom formatter sending the error text in plain text
+type upperFormatter struct{}
+
+func (upperFormatter) Format(report *telegramity.ErrorReport) ([]telegramity.Message, error) {
+	return []telegramity.Message{{Text: strings.ToUpper(report.Error.Error())}}, nil
+}
+
+func (upperFormatter) FormatSummary(summary telegramity.Summary) ([]telegramity.Message, error) {
+	return nil, nil
+}
+
+func TestCustomFormatter(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient, telegramity.WithFormatter(upperFormatter{}))
+
+	if err := client.ReportError(context.Background(), errors.New("boom"), "test_type"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if sent := botClient.sent(); len(sent) != 1 || sent[0] != "BOOM" {
+		t.Fatalf("Expected [BOOM], got %q", sent)
+	}
+	if botClient.parseModes[0] != telegramity.ParseModeNone {
+		t.Errorf("Expected no parse mode, got %q", botClient.parseModes[0])
+	}
+}
+
+// contextFormatter sends the report context only, printed with sorted keys
+type contextFormatter struct{ upperFormatter }
+
+func (contextFormatter) Format(report *telegramity.ErrorReport) ([]telegramity.Message, error) {
+	return []telegramity.Message{{Text: fmt.Sprint(report.Context)}}, nil
+}
+
+func TestContextMerging(t *testing.T) {
+	tests := []struct {
+		name        string
+		defaults    map[string]interface{}
+		opts        []internalerrors.ErrorOption
+		callContext map[string]interface{}
+		want        string
+	}{
+		{
+			name:        "call_context_only",
+			callContext: map[string]interface{}{"a": 1},
+			want:        "map[a:1]",
+		},
+		{
+			name:        "options_and_call_context_merged",
+			opts:        []internalerrors.ErrorOption{telegramity.WithContextValue("order_id", "A1")},
+			callContext: map[string]interface{}{"amount": 10},
+			want:        "map[amount:10 order_id:A1]",
+		},
+		{
+			name:        "precedence",
+			defaults:    map[string]interface{}{"region": "eu", "pod": "api-1", "source": "default"},
+			opts:        []internalerrors.ErrorOption{telegramity.WithContext(map[string]interface{}{"pod": "api-2", "source": "option"})},
+			callContext: map[string]interface{}{"source": "call"},
+			want:        "map[pod:api-2 region:eu source:call]",
+		},
+		{
+			name:     "defaults_only",
+			defaults: map[string]interface{}{"region": "eu"},
+			want:     "map[region:eu]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			botClient := &recordingBotClient{}
+			client := newTestClient(t, botClient,
+				telegramity.WithFormatter(contextFormatter{}),
+				telegramity.WithDefaultContext(tt.defaults),
+			)
+
+			err := client.ReportErrorWithContext(context.Background(), errors.New("boom"), "test_type", tt.callContext, tt.opts...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if sent := botClient.sent(); len(sent) != 1 || sent[0] != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, sent)
+			}
+		})
+	}
+}
+
+func TestContextIsCopied(t *testing.T) {
+	botClient := &recordingBotClient{release: make(chan struct{})}
+	defaults := map[string]interface{}{"region": "eu"}
+	client := newTestClient(t, botClient,
+		telegramity.WithAsync(1, 10),
+		telegramity.WithFormatter(contextFormatter{}),
+		telegramity.WithDefaultContext(defaults),
+	)
+
+	callContext := map[string]interface{}{"attempt": 1}
+	if err := client.ReportErrorWithContext(context.Background(), errors.New("boom"), "test_type", callContext); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Mutating the caller's maps must not affect the queued report
+	callContext["attempt"] = 2
+	callContext["extra"] = true
+	defaults["region"] = "us"
+	close(botClient.release)
+
+	waitFor(t, func() bool { return client.Stats().Delivered == 1 })
+	if sent := botClient.sent(); sent[0] != "map[attempt:1 region:eu]" {
+		t.Errorf("Expected the context at the time of the call, got %q", sent[0])
+	}
+}
@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	internalerrors "github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+type requestIDKey struct{}
+
+// userFormatter sends the user ID and context of a report
+type userFormatter struct{ upperFormatter }
+
+func (userFormatter) Format(report *telegramity.ErrorReport) ([]telegramity.Message, error) {
+	return []telegramity.Message{{Text: fmt.Sprintf("%s %v", report.UserID, report.Context)}}, nil
+}
+
+func TestContextTagsAccumulate(t *testing.T) {
+	ctx := telegramity.ContextWithTags(context.Background(), map[string]interface{}{"a": 1, "b": 1})
+	parent := ctx
+	ctx = telegramity.ContextWithTag(ctx, "b", 2)
+
+	if got := fmt.Sprint(telegramity.TagsFromContext(ctx)); got != "map[a:1 b:2]" {
+		t.Errorf("Expected merged tags, got %s", got)
+	}
+	if got := fmt.Sprint(telegramity.TagsFromContext(parent)); got != "map[a:1 b:1]" {
+		t.Errorf("Expected parent tags to be unchanged, got %s", got)
+	}
+	if tags := telegramity.TagsFromContext(context.Background()); tags != nil {
+		t.Errorf("Expected no tags, got %v", tags)
+	}
+}
+
+func TestReportMetadataFromContext(t *testing.T) {
+	ctx := telegramity.ContextWithUser(context.Background(), "user-1")
+	ctx = telegramity.ContextWithTags(ctx, map[string]interface{}{"tenant": "acme", "source": "tag"})
+	ctx = context.WithValue(ctx, requestIDKey{}, "req-42")
+
+	tests := []struct {
+		name        string
+		opts        []internalerrors.ErrorOption
+		callContext map[string]interface{}
+		want        string
+	}{
+		{
+			name: "from_context",
+			want: "user-1 map[region:eu request_id:req-42 source:tag tenant:acme]",
+		},
+		{
+			name:        "explicit_values_win",
+			opts:        []internalerrors.ErrorOption{telegramity.WithUserID("user-2")},
+			callContext: map[string]interface{}{"source": "call", "request_id": "req-1"},
+			want:        "user-2 map[region:eu request_id:req-1 source:call tenant:acme]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			botClient := &recordingBotClient{}
+			client := newTestClient(t, botClient,
+				telegramity.WithFormatter(userFormatter{}),
+				telegramity.WithDefaultContext(map[string]interface{}{"region": "eu", "source": "default"}),
+				telegramity.WithContextExtractors(func(ctx context.Context, report *telegramity.ErrorReport) {
+					if _, ok := report.Context["request_id"]; ok {
+						return
+					}
+					if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+						report.Context["request_id"] = id
+					}
+				}),
+			)
+
+			err := client.ReportErrorWithContext(ctx, errors.New("boom"), "test_type", tt.callContext, tt.opts...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if sent := botClient.sent(); len(sent) != 1 || sent[0] != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	internalerrors "github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/internal/formatters"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+type orderInfo struct {
+	ID     string  `json:"id"`
+	Amount float64 `json:"amount"`
+}
+
+// renderContext formats a report carrying values and returns the lines of
+// its context section
+func renderContext(t *testing.T, values map[string]interface{}, options ...configs.ConfigOption) string {
+	t.Helper()
+
+	config := configs.DefaultConfig()
+	config.IncludeStackTrace = false
+	for _, option := range options {
+		option(&config)
+	}
+
+	report := internalerrors.CreateErrorReport(errors.New("test error"), "test_type", telegramity.WithContext(values))
+	message := formatSingle(t, formatters.NewPlainTextFormatter(&config), report).Text
+
+	_, context, found := strings.Cut(message, "📋 Context:\n")
+	if !found {
+		t.Fatalf("Expected a context section, got:\n%s", message)
+	}
+	return strings.TrimSuffix(context, "\n")
+}
+
+func TestContextRendering(t *testing.T) {
+	var nilOrder *orderInfo
+
+	tests := []struct {
+		name    string
+		values  map[string]interface{}
+		options []configs.ConfigOption
+		want    string
+	}{
+		{
+			name:   "sorted_keys",
+			values: map[string]interface{}{"zeta": 1, "alpha": "a", "mid": true},
+			want:   "alpha: a\nmid: true\nzeta: 1",
+		},
+		{
+			name: "nested_maps_and_slices",
+			values: map[string]interface{}{
+				"request": map[string]interface{}{"method": "GET", "ids": []int{1, 2}},
+				"empty":   map[string]string{},
+			},
+			want: "empty: {}\nrequest:\n  ids:\n    - 1\n    - 2\n  method: GET",
+		},
+		{
+			name: "max_depth",
+			values: map[string]interface{}{
+				"a": map[string]interface{}{"b": map[string]interface{}{"c": map[string]int{"d": 1}, "list": []int{1, 2, 3}}},
+			},
+			options: []configs.ConfigOption{telegramity.WithContextLimits(2, 0)},
+			want:    "a:\n  b: {… 2 keys}",
+		},
+		{
+			name: "structs_as_json",
+			values: map[string]interface{}{
+				"order":   orderInfo{ID: "A1", Amount: 9.5},
+				"pointer": &orderInfo{ID: "A2"},
+				"nil":     nilOrder,
+			},
+			want: "nil: <nil>\norder: {\"id\":\"A1\",\"amount\":9.5}\npointer: {\"id\":\"A2\",\"amount\":0}",
+		},
+		{
+			name: "errors_times_and_stringers",
+			values: map[string]interface{}{
+				"cause":   errors.New("timeout"),
+				"at":      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				"timeout": 1500 * time.Millisecond,
+			},
+			want: "at: 2024-01-02T03:04:05Z\ncause: timeout\ntimeout: 1.5s",
+		},
+		{
+			name:    "long_and_multiline_values",
+			values:  map[string]interface{}{"body": strings.Repeat("x", 50), "sql": "SELECT 1\nFROM t"},
+			options: []configs.ConfigOption{telegramity.WithContextLimits(0, 20)},
+			want:    "body: xxxxxxxxxxxxxxxxxxx…\nsql: \"SELECT 1\\nFROM t\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderContext(t, tt.values, tt.options...); got != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestContextRenderingFitsMessageLength(t *testing.T) {
+	values := make(map[string]interface{})
+	for i := 0; i < 500; i++ {
+		values[strings.Repeat("k", 5)+string(rune('a'+i%26))+strings.Repeat("<", i%3)] = strings.Repeat("v&", 40)
+	}
+
+	config := configs.DefaultConfig()
+	config.MaxMessageLength = 1000
+
+	report := internalerrors.CreateErrorReport(errors.New("test error"), "test_type", telegramity.WithContext(values))
+	message := formatSingle(t, formatters.NewErrorFormatter(&config), report)
+
+	assertValidMessage(t, message.Text, config.MaxMessageLength)
+	if !strings.Contains(message.Text, "… (truncated)") {
+		t.Errorf("Expected the context to be truncated, got:\n%s", message.Text)
+	}
+	if !message.Truncated {
+		t.Error("Expected the message to be marked as truncated")
+	}
+}
@@ -0,0 +1,163 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/somosbytes/telegramity/internal/dedup"
+	internalerrors "github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+func TestFingerprint(t *testing.T) {
+	report := func(message, errorType string, opts ...internalerrors.ErrorOption) *internalerrors.ErrorReport {
+		opts = append([]internalerrors.ErrorOption{telegramity.WithStackTrace("main.handler()\n\tmain.go:10")}, opts...)
+		return internalerrors.CreateErrorReport(errors.New(message), errorType, opts...)
+	}
+
+	tests := []struct {
+		name       string
+		a          *internalerrors.ErrorReport
+		b          *internalerrors.ErrorReport
+		expectSame bool
+	}{
+		{
+			name:       "numbers_normalized",
+			a:          report("order 1042 not found", "database"),
+			b:          report("order 77 not found", "database"),
+			expectSame: true,
+		},
+		{
+			name:       "uuids_normalized",
+			a:          report("user 3f2b8c1e-9d4a-4b6f-8e2a-1c3d5e7f9a0b missing", "auth"),
+			b:          report("user 00000000-0000-0000-0000-000000000000 missing", "auth"),
+			expectSame: true,
+		},
+		{
+			name:       "different_type",
+			a:          report("connection failed", "database"),
+			b:          report("connection failed", "network"),
+			expectSame: false,
+		},
+		{
+			name:       "different_stack",
+			a:          report("connection failed", "database"),
+			b:          report("connection failed", "database", telegramity.WithStackTrace("main.worker()\n\tmain.go:20")),
+			expectSame: false,
+		},
+		{
+			name:       "explicit_fingerprint",
+			a:          report("connection failed", "database", telegramity.WithFingerprint("db-down")),
+			b:          report("timeout", "network", telegramity.WithFingerprint("db-down")),
+			expectSame: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			same := dedup.Fingerprint(tt.a, 3) == dedup.Fingerprint(tt.b, 3)
+			if same != tt.expectSame {
+				t.Errorf("Expected same fingerprint = %v, got %v", tt.expectSame, same)
+			}
+		})
+	}
+}
+
+func TestDeduplicator(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	d := dedup.New(time.Minute)
+
+	at := func(offset time.Duration) *internalerrors.ErrorReport {
+		return internalerrors.CreateErrorReport(errors.New("db down"), "database", telegramity.WithTimestamp(start.Add(offset)))
+	}
+
+	if d.Observe("fp", at(0)) {
+		t.Fatalf("Expected first occurrence to be sent")
+	}
+	for _, offset := range []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second} {
+		if !d.Observe("fp", at(offset)) {
+			t.Fatalf("Expected duplicate at %v to be suppressed", offset)
+		}
+	}
+
+	if summaries := d.Flush(start.Add(30 * time.Second)); len(summaries) != 0 {
+		t.Fatalf("Expected no summaries before the window ends, got %d", len(summaries))
+	}
+
+	summaries := d.Flush(start.Add(time.Minute))
+	if len(summaries) != 1 {
+		t.Fatalf("Expected 1 summary, got %d", len(summaries))
+	}
+	summary := summaries[0]
+	if summary.Count != 3 {
+		t.Errorf("Expected count 3, got %d", summary.Count)
+	}
+	if !summary.FirstSeen.Equal(start) || !summary.LastSeen.Equal(start.Add(30*time.Second)) {
+		t.Errorf("Unexpected first/last seen: %v / %v", summary.FirstSeen, summary.LastSeen)
+	}
+
+	// A quiet window forgets the fingerprint, so the next occurrence is sent
+	if summaries := d.Flush(start.Add(2 * time.Minute)); len(summaries) != 0 {
+		t.Fatalf("Expected no summaries for a quiet window, got %d", len(summaries))
+	}
+	if d.Observe("fp", at(2*time.Minute+time.Second)) {
+		t.Errorf("Expected occurrence after a quiet window to be sent")
+	}
+}
+
+func TestDeduplicatorKeepsCopy(t *testing.T) {
+	d := dedup.New(time.Minute)
+	report := internalerrors.CreateErrorReport(errors.New("db down"), "database",
+		telegramity.WithContextValue("attempt", 1),
+	)
+	d.Observe("fp", report)
+
+	// The client goes on enriching and routing the report it observed
+	report.Context["attempt"] = 2
+	report.Runtime = append(report.Runtime, telegramity.RuntimeField{Name: "host", Value: "web-1"})
+	report.ChatIDs = []int64{42}
+	d.Observe("fp", internalerrors.CreateErrorReport(errors.New("db down"), "database"))
+
+	summaries := d.FlushAll(time.Now())
+	if len(summaries) != 1 {
+		t.Fatalf("Expected 1 summary, got %d", len(summaries))
+	}
+	kept := summaries[0].Report
+	if kept == report || kept.Context["attempt"] != 1 || len(kept.Runtime) != 0 || len(kept.ChatIDs) != 0 {
+		t.Errorf("Expected the deduplicator to keep the report as observed, got %+v", kept)
+	}
+}
+
+func TestClientDeduplication(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient, telegramity.WithDeduplication(time.Hour))
+
+	for i := 0; i < 3; i++ {
+		err := client.ReportError(context.Background(), errors.New("database connection failed"), telegramity.ErrorTypeDatabase)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if got := len(botClient.sent()); got != 1 {
+		t.Fatalf("Expected 1 message before shutdown, got %d", got)
+	}
+	if stats := client.Stats(); stats.Suppressed != 2 {
+		t.Errorf("Expected 2 suppressed reports, got %d", stats.Suppressed)
+	}
+
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	messages := botClient.sent()
+	if len(messages) != 2 {
+		t.Fatalf("Expected summary message on shutdown, got %d messages", len(messages))
+	}
+	if !strings.Contains(messages[1], "2 more times since") {
+		t.Errorf("Expected summary with count, got:\n%s", messages[1])
+	}
+}
@@ -0,0 +1,83 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+func TestRuntimeInfo(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient,
+		telegramity.WithAppInfo("MyApp", "1.2.3"),
+		telegramity.WithRuntimeInfo(),
+	)
+
+	if err := client.ReportError(context.Background(), errors.New("boom"), "test_type"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	sent := botClient.sent()
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(sent))
+	}
+
+	hostname, _ := os.Hostname()
+	for _, want := range []string{
+		"MyApp 1.2.3",
+		"<b>Runtime:</b>\nhost: ",
+		"host: " + hostname + " (pid ",
+		"go: " + runtime.Version() + " " + runtime.GOOS + "/" + runtime.GOARCH,
+		"goroutines: ",
+		"memory: heap ",
+	} {
+		if !strings.Contains(sent[0], want) {
+			t.Errorf("Expected message to contain %q, got:\n%s", want, sent[0])
+		}
+	}
+}
+
+func TestCustomEnricher(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient,
+		telegramity.WithEnrichers(
+			func(report *telegramity.ErrorReport) {
+				report.Runtime = append(report.Runtime, telegramity.RuntimeField{Name: "region", Value: "eu-west-1"})
+			},
+			telegramity.EnrichGoroutines(),
+		),
+	)
+
+	if err := client.ReportError(context.Background(), errors.New("boom"), "test_type"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	sent := botClient.sent()
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(sent))
+	}
+	if !strings.Contains(sent[0], "region: eu-west-1\ngoroutines: ") {
+		t.Errorf("Expected runtime fields in order, got:\n%s", sent[0])
+	}
+	if strings.Contains(sent[0], "host: ") {
+		t.Errorf("Expected only the selected enrichers, got:\n%s", sent[0])
+	}
+}
+
+func TestNoRuntimeSectionByDefault(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient)
+
+	if err := client.ReportError(context.Background(), errors.New("boom"), "test_type"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if sent := botClient.sent(); len(sent) != 1 || strings.Contains(sent[0], "Runtime:") {
+		t.Errorf("Expected no runtime section, got %q", sent)
+	}
+}
@@ -0,0 +1,320 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	internalerrors "github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/internal/formatters"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+var brokenEntity = regexp.MustCompile(`&[#a-zA-Z0-9]*($|[^;#a-zA-Z0-9])`)
+
+func longStackTrace(frames int) string {
+	var b strings.Builder
+	for i := 0; i < frames; i++ {
+		fmt.Fprintf(&b, "main.handler%d(&amp;state)\n\t/app/main.go:%d\n", i, i)
+	}
+	return b.String()
+}
+
+// assertValidMessage checks the invariants every formatted message must hold
+func assertValidMessage(t *testing.T, message string, limit int) {
+	t.Helper()
+
+	if n := len(utf16.Encode([]rune(message))); n > limit {
+		t.Errorf("Expected at most %d UTF-16 units, got %d", limit, n)
+	}
+	if !utf8.ValidString(message) {
+		t.Errorf("Message is not valid UTF-8")
+	}
+	for _, tag := range []string{"b", "i", "pre", "code"} {
+		if strings.Count(message, "<"+tag+">") != strings.Count(message, "</"+tag+">") {
+			t.Errorf("Unbalanced <%s> tags in:\n%s", tag, message)
+		}
+	}
+	if brokenEntity.MatchString(message) {
+		t.Errorf("Message contains a broken entity:\n%s", message)
+	}
+}
+
+func TestFormatterMessageLength(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       int
+		split       bool
+		err         error
+		stackFrames int
+		expectParts int
+		truncated   bool
+	}{
+		{
+			name:        "short_report_untouched",
+			limit:       4096,
+			err:         errors.New("short"),
+			stackFrames: 2,
+			expectParts: 1,
+		},
+		{
+			name:        "long_stack_truncated",
+			limit:       600,
+			err:         errors.New("database connection failed"),
+			stackFrames: 40,
+			expectParts: 1,
+			truncated:   true,
+		},
+		{
+			name:        "long_error_with_surrogate_pairs_truncated",
+			limit:       300,
+			err:         errors.New(strings.Repeat("🔥", 400)),
+			stackFrames: 10,
+			expectParts: 1,
+			truncated:   true,
+		},
+		{
+			name:        "long_stack_split",
+			limit:       600,
+			split:       true,
+			err:         errors.New("database connection failed"),
+			stackFrames: 40,
+			expectParts: 2,
+			truncated:   true, // Frames beyond the stack trace limit are left out
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configs.DefaultConfig()
+			config.MaxMessageLength = tt.limit
+			config.SplitLongMessages = tt.split
+
+			report := internalerrors.CreateErrorReport(tt.err, "database",
+				telegramity.WithStackTrace(longStackTrace(tt.stackFrames)),
+			)
+
+			messages, err := formatters.NewErrorFormatter(&config).Format(report)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(messages) != tt.expectParts {
+				t.Errorf("Expected %d messages, got %d", tt.expectParts, len(messages))
+			}
+			for _, message := range messages {
+				assertValidMessage(t, message.Text, tt.limit)
+				if message.Truncated != tt.truncated {
+					t.Errorf("Expected Truncated to be %v, got %v", tt.truncated, message.Truncated)
+				}
+			}
+			if len(messages) > 1 && !strings.HasPrefix(messages[0].Text, "<i>(1/") {
+				t.Errorf("Expected split messages to be numbered, got:\n%s", messages[0].Text)
+			}
+		})
+	}
+}
+
+func TestFormatterEscapesDynamicFields(t *testing.T) {
+	config := configs.DefaultConfig()
+
+	report := internalerrors.CreateErrorReport(errors.New("unexpected <nil> & map[a:<b>]"), "<type>",
+		telegramity.WithUserID("<admin>"),
+		telegramity.WithContextValue("html", "<script>"),
+		telegramity.WithStackTrace("main.run(<nil>)\n\tmain.go:1"),
+	)
+
+	message := formatSingle(t, formatters.NewErrorFormatter(&config), report).Text
+
+	for _, raw := range []string{"<nil>", "<b>]", "<type>", "<admin>", "<script>"} {
+		if strings.Contains(message, raw) {
+			t.Errorf("Expected %q to be escaped, got:\n%s", raw, message)
+		}
+	}
+	if !strings.Contains(message, "unexpected &lt;nil&gt; &amp; map[a:&lt;b&gt;]") {
+		t.Errorf("Expected escaped error text, got:\n%s", message)
+	}
+	assertValidMessage(t, message, 4096)
+}
+
+// formatSingle formats a report that must fit in a single message
+func formatSingle(t *testing.T, formatter configs.Formatter, report *internalerrors.ErrorReport) configs.Message {
+	t.Helper()
+
+	messages, err := formatter.Format(report)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	return messages[0]
+}
+
+func TestStripHTML(t *testing.T) {
+	got := formatters.StripHTML("❌ <b>Error:</b> a &lt;nil&gt; &amp; b\n<pre><code>x</code></pre>")
+	want := "❌ Error: a <nil> & b\nx"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+// assertValidMarkdownV2 checks that every reserved character outside a
+// code block is escaped, except for balanced bold and italic delimiters
+func assertValidMarkdownV2(t *testing.T, message string) {
+	t.Helper()
+
+	blocks := strings.Split(message, "```")
+	if len(blocks)%2 == 0 {
+		t.Fatalf("Unbalanced code block in:\n%s", message)
+	}
+
+	delimiters := map[rune]int{}
+	for i, block := range blocks {
+		code := i%2 == 1
+		escaped := false
+		for _, r := range block {
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case code && r == '`':
+				t.Errorf("Unescaped ` inside code block:\n%s", message)
+			case !code && (r == '*' || r == '_'):
+				delimiters[r]++
+			case !code && strings.ContainsRune("[]()~`>#+-=|{}.!", r):
+				t.Errorf("Unescaped %q in:\n%s", r, message)
+			}
+		}
+		if escaped {
+			t.Errorf("Dangling backslash in:\n%s", message)
+		}
+	}
+	for r, n := range delimiters {
+		if n%2 != 0 {
+			t.Errorf("Unbalanced %q delimiters in:\n%s", r, message)
+		}
+	}
+}
+
+func TestFormatterParseModes(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter func(*configs.Config) *formatters.ErrorFormatter
+		parseMode string
+		split     bool
+		contains  []string
+		excludes  []string
+	}{
+		{
+			name:      "html",
+			formatter: formatters.NewHTMLFormatter,
+			parseMode: configs.ParseModeHTML,
+			contains:  []string{"<b>Error:</b>", "<pre><code>", "a_b*c [x](y) 1.5-2!"},
+		},
+		{
+			name:      "markdown_v2",
+			formatter: formatters.NewMarkdownV2Formatter,
+			parseMode: configs.ParseModeMarkdownV2,
+			contains:  []string{"*Error:*", "```\n", `a\_b\*c \[x\]\(y\) 1\.5\-2\!`, "main.\\`quoted\\`(\\\\)"},
+			excludes:  []string{"<b>"},
+		},
+		{
+			name:      "markdown_v2_split",
+			formatter: formatters.NewMarkdownV2Formatter,
+			parseMode: configs.ParseModeMarkdownV2,
+			split:     true,
+			contains:  []string{`_\(1/`},
+		},
+		{
+			name:      "plain_text",
+			formatter: formatters.NewPlainTextFormatter,
+			parseMode: configs.ParseModeNone,
+			contains:  []string{"Error: a_b*c [x](y) 1.5-2!"},
+			excludes:  []string{"<b>", "*Error", `\_`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configs.DefaultConfig()
+			config.MaxMessageLength = 400
+			config.SplitLongMessages = tt.split
+
+			report := internalerrors.CreateErrorReport(errors.New("a_b*c [x](y) 1.5-2!"), "database",
+				telegramity.WithStackTrace("main.`quoted`(\\)\n"+longStackTrace(40)),
+			)
+
+			messages, err := tt.formatter(&config).Format(report)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tt.split && len(messages) < 2 {
+				t.Fatalf("Expected several messages, got %d", len(messages))
+			}
+
+			text := ""
+			for _, message := range messages {
+				if message.ParseMode != tt.parseMode {
+					t.Errorf("Expected parse mode %q, got %q", tt.parseMode, message.ParseMode)
+				}
+				if message.ParseMode == configs.ParseModeMarkdownV2 {
+					assertValidMarkdownV2(t, message.Text)
+				}
+				if n := len(utf16.Encode([]rune(message.Text))); n > config.MaxMessageLength {
+					t.Errorf("Expected at most %d UTF-16 units, got %d", config.MaxMessageLength, n)
+				}
+				text += message.Text
+			}
+
+			for _, s := range tt.contains {
+				if !strings.Contains(text, s) {
+					t.Errorf("Expected message to contain %q, got:\n%s", s, text)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(text, s) {
+					t.Errorf("Expected message not to contain %q, got:\n%s", s, text)
+				}
+			}
+		})
+	}
+}
+
+func TestPlainTextFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		message configs.Message
+		want    string
+	}{
+		{
+			name:    "html",
+			message: configs.Message{Text: "❌ <b>Error:</b> a &lt;nil&gt;", ParseMode: configs.ParseModeHTML},
+			want:    "❌ Error: a <nil>",
+		},
+		{
+			name:    "markdown_v2",
+			message: configs.Message{Text: "❌ *Error:* a\\_b \\(1\\.5\\) \\\\\n```\nx\n```", ParseMode: configs.ParseModeMarkdownV2},
+			want:    "❌ Error: a_b (1.5) \\\n\nx\n",
+		},
+		{
+			name:    "plain",
+			message: configs.Message{Text: "a *b*"},
+			want:    "a *b*",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatters.PlainText(tt.message)
+			if got.Text != tt.want || got.ParseMode != configs.ParseModeNone {
+				t.Errorf("Expected %q without parse mode, got %q (%q)", tt.want, got.Text, got.ParseMode)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	internalerrors "github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+func TestErrorOptions(t *testing.T) {
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	report := internalerrors.CreateErrorReport(
+		errors.New("payment declined"),
+		telegramity.ErrorTypePayment,
+		telegramity.WithSeverity(telegramity.SeverityCritical),
+		telegramity.WithUserID("user123"),
+		telegramity.WithEnvironment("staging"),
+		telegramity.WithAppName("billing"),
+		telegramity.WithStackTrace("main.go:42"),
+		telegramity.WithTimestamp(timestamp),
+		telegramity.WithFingerprint("payment-declined"),
+	)
+
+	if report.Severity != telegramity.SeverityCritical {
+		t.Errorf("Expected severity %q, got %q", telegramity.SeverityCritical, report.Severity)
+	}
+	if report.UserID != "user123" {
+		t.Errorf("Expected user ID %q, got %q", "user123", report.UserID)
+	}
+	if report.Environment != "staging" {
+		t.Errorf("Expected environment %q, got %q", "staging", report.Environment)
+	}
+	if report.AppName != "billing" {
+		t.Errorf("Expected app name %q, got %q", "billing", report.AppName)
+	}
+	if report.StackTrace != "main.go:42" {
+		t.Errorf("Expected stack trace %q, got %q", "main.go:42", report.StackTrace)
+	}
+	if !report.Timestamp.Equal(timestamp) {
+		t.Errorf("Expected timestamp %v, got %v", timestamp, report.Timestamp)
+	}
+	if report.Fingerprint != "payment-declined" {
+		t.Errorf("Expected fingerprint %q, got %q", "payment-declined", report.Fingerprint)
+	}
+}
+
+func TestContextOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []internalerrors.ErrorOption
+		expected map[string]interface{}
+	}{
+		{
+			name: "single_value",
+			opts: []internalerrors.ErrorOption{
+				telegramity.WithContextValue("order_id", "A-1"),
+			},
+			expected: map[string]interface{}{"order_id": "A-1"},
+		},
+		{
+			name: "merge_map_and_values",
+			opts: []internalerrors.ErrorOption{
+				telegramity.WithContext(map[string]interface{}{"order_id": "A-1", "amount": 10}),
+				telegramity.WithContextValue("amount", 20),
+			},
+			expected: map[string]interface{}{"order_id": "A-1", "amount": 20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := internalerrors.CreateErrorReport(errors.New("test error"), "test_type", tt.opts...)
+
+			if len(report.Context) != len(tt.expected) {
+				t.Fatalf("Expected %d context values, got %d", len(tt.expected), len(report.Context))
+			}
+			for k, v := range tt.expected {
+				if report.Context[k] != v {
+					t.Errorf("Expected context[%q] = %v, got %v", k, v, report.Context[k])
+				}
+			}
+		})
+	}
+}
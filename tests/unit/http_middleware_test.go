@@ -0,0 +1,208 @@
+package unit
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		handler       http.HandlerFunc
+		opts          telegramity.HTTPOptions
+		expectStatus  int
+		expectReports int
+		expectContent []string
+	}{
+		{
+			name: "ok_response_not_reported",
+			path: "/api/orders",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+			},
+			expectStatus:  http.StatusOK,
+			expectReports: 0,
+		},
+		{
+			name: "server_error_reported",
+			path: "/api/orders?id=42&token=secret-value",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			expectStatus:  http.StatusBadGateway,
+			expectReports: 1,
+			expectContent: []string{"GET /api/orders returned 502", "id=42", "token=[REDACTED]", "req-1", "203.0.113.7"},
+		},
+		{
+			name: "panic_recovered_and_reported",
+			path: "/api/orders",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic("handler exploded")
+			},
+			expectStatus:  http.StatusInternalServerError,
+			expectReports: 1,
+			expectContent: []string{"panic: handler exploded", "critical"},
+		},
+		{
+			name: "excluded_path_not_reported",
+			path: "/health/live",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			opts:          telegramity.HTTPOptions{ExcludePaths: []string{"/health/*"}},
+			expectStatus:  http.StatusServiceUnavailable,
+			expectReports: 0,
+		},
+		{
+			name: "custom_status_range",
+			path: "/api/orders",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			opts:          telegramity.HTTPOptions{StatusRanges: []telegramity.StatusRange{{Min: 429, Max: 429}}},
+			expectStatus:  http.StatusTooManyRequests,
+			expectReports: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			botClient := &recordingBotClient{}
+			client := newTestClient(t, botClient)
+
+			handler := telegramity.HTTPMiddleware(client, tt.opts)(tt.handler)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = "203.0.113.7:51234"
+			req.Header.Set("X-Request-ID", "req-1")
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectStatus, rec.Code)
+			}
+
+			messages := botClient.sent()
+			if len(messages) != tt.expectReports {
+				t.Fatalf("Expected %d reports, got %d", tt.expectReports, len(messages))
+			}
+			for _, want := range tt.expectContent {
+				if !strings.Contains(messages[0], want) {
+					t.Errorf("Expected report to contain %q, got:\n%s", want, messages[0])
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPMiddlewareEarlyHints(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient)
+
+	handler := telegramity.HTTPMiddleware(client, telegramity.HTTPOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</app.css>; rel=preload")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/orders")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", resp.StatusCode)
+	}
+
+	// The final status is reported, not the informational one
+	waitFor(t, func() bool { return len(botClient.sent()) == 1 })
+	if message := botClient.sent()[0]; !strings.Contains(message, "GET /api/orders returned 500") {
+		t.Errorf("Expected the 500 to be reported, got:\n%s", message)
+	}
+}
+
+func TestHTTPMiddlewareHijack(t *testing.T) {
+	tests := []struct {
+		name          string
+		afterHijack   func(conn net.Conn)
+		expectReports int
+	}{
+		{
+			name:          "upgrade_not_reported",
+			afterHijack:   func(conn net.Conn) {},
+			expectReports: 0,
+		},
+		{
+			name:          "panic_after_hijack_reported",
+			afterHijack:   func(conn net.Conn) { panic("socket exploded") },
+			expectReports: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			botClient := &recordingBotClient{}
+			client := newTestClient(t, botClient)
+
+			handler := telegramity.HTTPMiddleware(client, telegramity.HTTPOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hijacker, ok := w.(http.Hijacker)
+				if !ok {
+					t.Error("Expected the middleware to keep http.Hijacker")
+					return
+				}
+				conn, _, err := hijacker.Hijack()
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+				defer conn.Close()
+				_, _ = io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+				tt.afterHijack(conn)
+			}))
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			conn, err := net.Dial("tcp", server.Listener.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			_, _ = io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: example.com\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+
+			resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if resp.StatusCode != http.StatusSwitchingProtocols {
+				t.Errorf("Expected status 101, got %d", resp.StatusCode)
+			}
+
+			// The handler reports after the response reached the client
+			if tt.expectReports > 0 {
+				waitFor(t, func() bool { return len(botClient.sent()) >= tt.expectReports })
+			}
+			server.Close()
+
+			messages := botClient.sent()
+			if len(messages) != tt.expectReports {
+				t.Fatalf("Expected %d reports, got %d", tt.expectReports, len(messages))
+			}
+			for _, message := range messages {
+				if !strings.Contains(message, "hijacked") || strings.Contains(message, "status") {
+					t.Errorf("Expected a report without status, got:\n%s", message)
+				}
+			}
+		})
+	}
+}
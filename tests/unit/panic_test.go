@@ -0,0 +1,183 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	internalerrors "github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+func panickingFunction() {
+	panic("something exploded")
+}
+
+func TestPanicStackTrace(t *testing.T) {
+	var stack string
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				stack = internalerrors.PanicStackTrace(debug.Stack())
+			}
+		}()
+		panickingFunction()
+	}()
+
+	if !strings.HasPrefix(stack, "github.com/somosbytes/telegramity/tests/unit.panickingFunction") {
+		t.Errorf("Expected stack to start at the panicking function, got:\n%s", stack)
+	}
+	if strings.Contains(stack, "runtime/debug.Stack") {
+		t.Errorf("Expected recover frames to be trimmed, got:\n%s", stack)
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	cause := errors.New("nil map write")
+
+	tests := []struct {
+		name          string
+		value         interface{}
+		expectMessage string
+		expectUnwrap  error
+	}{
+		{
+			name:          "string_value",
+			value:         "boom",
+			expectMessage: "panic: boom",
+			expectUnwrap:  nil,
+		},
+		{
+			name:          "error_value",
+			value:         cause,
+			expectMessage: "panic: nil map write",
+			expectUnwrap:  cause,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &internalerrors.PanicError{Value: tt.value}
+
+			if err.Error() != tt.expectMessage {
+				t.Errorf("Expected message %q, got %q", tt.expectMessage, err.Error())
+			}
+			if errors.Unwrap(err) != tt.expectUnwrap {
+				t.Errorf("Expected unwrap %v, got %v", tt.expectUnwrap, errors.Unwrap(err))
+			}
+		})
+	}
+}
+
+func TestRecoverWithoutGlobalClient(t *testing.T) {
+	resetSingletonForTesting()
+
+	done := make(chan struct{})
+	telegramity.Go(func() {
+		defer close(done)
+		panic("unreported")
+	})
+	<-done
+}
+
+// installPanicClient installs a global client backed by botClient and
+// returns a function that returns the reports it accepted
+func installPanicClient(t *testing.T, botClient *recordingBotClient, options ...configs.ConfigOption) func() []*telegramity.ErrorReport {
+	t.Helper()
+
+	var mu sync.Mutex
+	var reports []*telegramity.ErrorReport
+	options = append(options, telegramity.WithProcessors(func(ctx context.Context, report *telegramity.ErrorReport) (*telegramity.ErrorReport, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, report)
+		return report, true
+	}))
+
+	telegramity.SetGlobalClient(newTestClient(t, botClient, options...))
+	t.Cleanup(func() { telegramity.SetGlobalClient(nil) })
+
+	return func() []*telegramity.ErrorReport {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*telegramity.ErrorReport(nil), reports...)
+	}
+}
+
+// assertPanicReport checks a report of panickingFunction
+func assertPanicReport(t *testing.T, report *telegramity.ErrorReport) {
+	t.Helper()
+
+	var panicErr *internalerrors.PanicError
+	if !errors.As(report.Error, &panicErr) || panicErr.Value != "something exploded" {
+		t.Errorf("Expected the panic value as the error, got %v", report.Error)
+	}
+	if report.ErrorType != telegramity.ErrorTypePanic || report.Severity != telegramity.SeverityCritical {
+		t.Errorf("Expected a critical panic report, got %s %s", report.ErrorType, report.Severity)
+	}
+	if !strings.HasPrefix(report.StackTrace, "github.com/somosbytes/telegramity/tests/unit.panickingFunction") {
+		t.Errorf("Expected stack to start at the panicking function, got:\n%s", report.StackTrace)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	botClient := &recordingBotClient{}
+	reports := installPanicClient(t, botClient)
+
+	func() {
+		defer telegramity.Recover(context.Background(), telegramity.WithContextValue("job", "sync"))
+		panickingFunction()
+	}()
+
+	got := reports()
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 report, got %d", len(got))
+	}
+	assertPanicReport(t, got[0])
+	if got[0].Context["job"] != "sync" {
+		t.Errorf("Expected the report options to apply, got %v", got[0].Context)
+	}
+	if len(botClient.sent()) != 1 {
+		t.Errorf("Expected the report to be sent")
+	}
+}
+
+func TestGo(t *testing.T) {
+	botClient := &recordingBotClient{}
+	reports := installPanicClient(t, botClient)
+
+	telegramity.Go(panickingFunction)
+
+	waitFor(t, func() bool { return len(botClient.sent()) == 1 })
+	got := reports()
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 report, got %d", len(got))
+	}
+	assertPanicReport(t, got[0])
+}
+
+func TestRecoverAndRepanic(t *testing.T) {
+	botClient := &recordingBotClient{}
+	// Delivered by a worker, so the report is only sent if the helper flushes
+	reports := installPanicClient(t, botClient, telegramity.WithAsync(1, 10))
+
+	var repanicked interface{}
+	func() {
+		defer func() { repanicked = recover() }()
+		defer telegramity.RecoverAndRepanic(context.Background())
+		panickingFunction()
+	}()
+
+	if repanicked != "something exploded" {
+		t.Errorf("Expected the panic to propagate with its value, got %v", repanicked)
+	}
+	if len(botClient.sent()) != 1 {
+		t.Fatalf("Expected the report to be delivered before panicking again, got %d messages", len(botClient.sent()))
+	}
+	assertPanicReport(t, reports()[0])
+}
@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+type tenantKey struct{}
+
+func TestProcessorsModifyReport(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient,
+		telegramity.WithProcessors(
+			func(ctx context.Context, report *telegramity.ErrorReport) (*telegramity.ErrorReport, bool) {
+				report.Context["tenant"] = ctx.Value(tenantKey{})
+				return report, true
+			},
+			func(ctx context.Context, report *telegramity.ErrorReport) (*telegramity.ErrorReport, bool) {
+				if report.Context["tenant"] == "acme" {
+					report.Severity = telegramity.SeverityCritical
+				}
+				return report, true
+			},
+		),
+	)
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	if err := client.ReportError(ctx, errors.New("boom"), "test_type"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	sent := botClient.sent()
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(sent))
+	}
+	for _, want := range []string{"tenant: acme", "critical"} {
+		if !strings.Contains(sent[0], want) {
+			t.Errorf("Expected message to contain %q, got:\n%s", want, sent[0])
+		}
+	}
+}
+
+func TestProcessorReplacesReport(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient,
+		telegramity.WithProcessors(func(ctx context.Context, report *telegramity.ErrorReport) (*telegramity.ErrorReport, bool) {
+			replaced := *report
+			replaced.Error = errors.New("rewritten")
+			return &replaced, true
+		}),
+	)
+
+	if err := client.ReportError(context.Background(), errors.New("boom"), "test_type"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if sent := botClient.sent(); len(sent) != 1 || !strings.Contains(sent[0], "rewritten") {
+		t.Errorf("Expected the replaced report to be sent, got %q", sent)
+	}
+}
+
+func TestProcessorDropsReport(t *testing.T) {
+	tests := []struct {
+		name      string
+		processor telegramity.Processor
+	}{
+		{
+			name: "false",
+			processor: func(ctx context.Context, report *telegramity.ErrorReport) (*telegramity.ErrorReport, bool) {
+				return report, false
+			},
+		},
+		{
+			name: "nil",
+			processor: func(ctx context.Context, report *telegramity.ErrorReport) (*telegramity.ErrorReport, bool) {
+				return nil, true
+			},
+		},
+		{
+			name: "nil_error",
+			processor: func(ctx context.Context, report *telegramity.ErrorReport) (*telegramity.ErrorReport, bool) {
+				return &telegramity.ErrorReport{ErrorType: report.ErrorType}, true
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			botClient := &recordingBotClient{}
+			client := newTestClient(t, botClient,
+				telegramity.WithAsync(1, 10),
+				telegramity.WithProcessors(tt.processor, func(ctx context.Context, report *telegramity.ErrorReport) (*telegramity.ErrorReport, bool) {
+					called = true
+					return report, true
+				}),
+			)
+
+			if err := client.ReportError(context.Background(), errors.New("boom"), "test_type"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := client.Flush(context.Background()); err != nil {
+				t.Fatalf("Unexpected flush error: %v", err)
+			}
+
+			if sent := botClient.sent(); len(sent) != 0 {
+				t.Errorf("Expected no messages, got %q", sent)
+			}
+			if called {
+				t.Error("Expected later processors to be skipped")
+			}
+			if stats := client.Stats(); stats.Filtered != 1 {
+				t.Errorf("Expected 1 filtered report, got %d", stats.Filtered)
+			}
+		})
+	}
+}
+
+func TestPanickingProcessorReleasesReport(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient,
+		telegramity.WithProcessors(func(ctx context.Context, report *telegramity.ErrorReport) (*telegramity.ErrorReport, bool) {
+			panic("processor bug")
+		}),
+	)
+
+	func() {
+		defer func() { _ = recover() }()
+		_ = client.ReportError(context.Background(), errors.New("boom"), "test_type")
+	}()
+
+	// The report must not stay pending
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Flush(ctx); err != nil {
+		t.Errorf("Expected flush to return at once, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/internal/ratelimit"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+func TestLimiterBurst(t *testing.T) {
+	limiter := ratelimit.New(1, 3, 0)
+
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow(false) {
+			t.Fatalf("Expected token %d of the burst to be available", i+1)
+		}
+	}
+	if limiter.Allow(false) {
+		t.Errorf("Expected bucket to be empty after the burst")
+	}
+}
+
+func TestLimiterReserve(t *testing.T) {
+	limiter := ratelimit.New(1, 3, 1)
+
+	if !limiter.Allow(false) || !limiter.Allow(false) {
+		t.Fatalf("Expected unreserved tokens to be available")
+	}
+	if limiter.Allow(false) {
+		t.Errorf("Expected reserved token to be unavailable to normal callers")
+	}
+	if !limiter.Allow(true) {
+		t.Errorf("Expected reserved token to be available to priority callers")
+	}
+	if limiter.Allow(true) {
+		t.Errorf("Expected bucket to be empty")
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	t.Run("refills_over_time", func(t *testing.T) {
+		limiter := ratelimit.New(100, 1, 0)
+		limiter.Allow(false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		if err := limiter.Wait(ctx, false); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("fractional_rate", func(t *testing.T) {
+		limiter := ratelimit.New(20.0/60.0, 1, 0)
+		limiter.Allow(false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		if err := limiter.Wait(ctx, false); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		limiter := ratelimit.New(0, 1, 0)
+
+		for i := 0; i < 100; i++ {
+			if !limiter.Allow(false) {
+				t.Fatalf("Expected unlimited limiter to always allow")
+			}
+		}
+	})
+}
+
+func TestRateLimitValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []configs.ConfigOption
+		wantErr string
+	}{
+		{
+			name:    "zero_burst",
+			options: []configs.ConfigOption{telegramity.WithRateLimitBurst(0)},
+			wantErr: "rate limit burst must be at least 1",
+		},
+		{
+			name: "reserve_not_below_burst",
+			options: []configs.ConfigOption{
+				telegramity.WithRateLimitBurst(2),
+				telegramity.WithRateLimitReserve(2, telegramity.SeverityCritical),
+			},
+			wantErr: "rate limit reserve must be between 0 and burst-1",
+		},
+		{
+			name:    "zero_interval",
+			options: []configs.ConfigOption{telegramity.WithRateLimitPer(20, 0)},
+			wantErr: "rate limit must be a finite number of messages per second",
+		},
+		{
+			name:    "zero_messages_per_zero_interval",
+			options: []configs.ConfigOption{telegramity.WithRateLimitPer(0, 0)},
+			wantErr: "rate limit must be a finite number of messages per second",
+		},
+		{
+			name:    "negative_interval",
+			options: []configs.ConfigOption{telegramity.WithRateLimitPer(20, -time.Minute)},
+			wantErr: "rate limit must be a finite number of messages per second",
+		},
+		{
+			name:    "per_minute",
+			options: []configs.ConfigOption{telegramity.WithRateLimitPer(20, time.Minute)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configs.DefaultConfig()
+			config.BotToken = "test_token"
+			config.Chat = configs.ChatID(123456789)
+			for _, option := range tt.options {
+				option(&config)
+			}
+
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLimiterPause(t *testing.T) {
+	for _, rate := range []float64{0, 100} {
+		limiter := ratelimit.New(rate, 3, 0)
+		limiter.PauseUntil(time.Now().Add(time.Hour))
+		limiter.PauseUntil(time.Now()) // Does not shorten the pause
+
+		if limiter.Allow(true) {
+			t.Errorf("Expected paused limiter with rate %v to refuse tokens", rate)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		if err := limiter.Wait(ctx, true); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+		cancel()
+	}
+
+	limiter := ratelimit.New(0, 1, 0)
+	limiter.PauseUntil(time.Now().Add(20 * time.Millisecond))
+	if err := limiter.Wait(context.Background(), false); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	internalerrors "github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/internal/telegram/bot"
+	internaltelegramity "github.com/somosbytes/telegramity/internal/telegramity"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+func TestRouting(t *testing.T) {
+	routes := []telegramity.Route{
+		{ErrorTypes: []string{telegramity.ErrorTypePayment}, ChatIDs: []int64{10}},
+		{MinSeverity: telegramity.SeverityCritical, Environments: []string{"production"}, ChatIDs: []int64{20, 123456789}},
+		{ContextKeys: []string{"tenant"}, ContextValues: map[string]string{"tier": "1"}, ChatIDs: []int64{30}},
+	}
+
+	tests := []struct {
+		name      string
+		errorType string
+		opts      []internalerrors.ErrorOption
+		want      []int64
+	}{
+		{
+			name:      "no_match_uses_default",
+			errorType: telegramity.ErrorTypeAuth,
+			want:      []int64{123456789},
+		},
+		{
+			name:      "error_type",
+			errorType: telegramity.ErrorTypePayment,
+			want:      []int64{10},
+		},
+		{
+			name:      "severity_below_minimum",
+			errorType: telegramity.ErrorTypeDatabase,
+			opts:      []internalerrors.ErrorOption{telegramity.WithSeverity(telegramity.SeverityHigh), telegramity.WithEnvironment("production")},
+			want:      []int64{123456789},
+		},
+		{
+			name:      "wrong_environment",
+			errorType: telegramity.ErrorTypeDatabase,
+			opts:      []internalerrors.ErrorOption{telegramity.WithSeverity(telegramity.SeverityCritical), telegramity.WithEnvironment("staging")},
+			want:      []int64{123456789},
+		},
+		{
+			name:      "fan_out_without_duplicates",
+			errorType: telegramity.ErrorTypePayment,
+			opts: []internalerrors.ErrorOption{
+				telegramity.WithSeverity(telegramity.SeverityCritical),
+				telegramity.WithEnvironment("production"),
+				telegramity.WithContext(map[string]interface{}{"tenant": "acme", "tier": 1}),
+			},
+			want: []int64{10, 20, 123456789, 30},
+		},
+		{
+			name:      "context_value_mismatch",
+			errorType: telegramity.ErrorTypeAuth,
+			opts:      []internalerrors.ErrorOption{telegramity.WithContext(map[string]interface{}{"tenant": "acme", "tier": 2})},
+			want:      []int64{123456789},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			botClient := &recordingBotClient{}
+			client := newTestClient(t, botClient,
+				telegramity.WithRoutes(routes...),
+				telegramity.WithEnvironmentName("development"),
+			)
+
+			if err := client.ReportError(context.Background(), errors.New("boom"), tt.errorType, tt.opts...); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if got := botClient.sentTo(); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Expected chats %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRoutingPartialFailure(t *testing.T) {
+	botClient := &recordingBotClient{chatErrs: map[int64]error{
+		10: &bot.SendError{Kind: bot.KindForbidden, Code: 403, Description: "Forbidden: bot was kicked"},
+	}}
+	client := newTestClient(t, botClient,
+		telegramity.WithRoutes(telegramity.Route{ChatIDs: []int64{10, 20}}),
+	)
+
+	err := client.ReportError(context.Background(), errors.New("boom"), "test_type")
+	if err == nil {
+		t.Fatal("Expected an error for the failed chat")
+	}
+
+	if got := botClient.sentTo(); len(got) != 1 || got[0] != 20 {
+		t.Errorf("Expected delivery to the remaining chat, got %v", got)
+	}
+	if stats := client.Stats(); stats.Failed != 1 {
+		t.Errorf("Expected 1 failed report, got %d", stats.Failed)
+	}
+}
+
+func TestRouteValidation(t *testing.T) {
+	_, err := internaltelegramity.NewClient("token", 123456789, telegramity.WithRoutes(telegramity.Route{ErrorTypes: []string{"x"}}))
+	if err == nil {
+		t.Error("Expected an error for a route without chats")
+	}
+}
@@ -0,0 +1,239 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/somosbytes/telegramity/internal/telegram/bot"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+// scriptedBotClient fails sends with the scripted errors, in order, and
+// succeeds once they run out
+type scriptedBotClient struct {
+	mu         sync.Mutex
+	errs       []error
+	calls      []time.Time
+	messages   []string
+	parseModes []string
+}
+
+func (m *scriptedBotClient) SendMessage(ctx context.Context, chatID int64, message string) error {
+	_, err := m.SendMessageWithOptions(ctx, chatID, message, bot.SendOptions{ParseMode: bot.ParseModeHTML})
+	return err
+}
+
+func (m *scriptedBotClient) SendMessageWithOptions(ctx context.Context, chatID int64, message string, opts bot.SendOptions) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, time.Now())
+	m.messages = append(m.messages, message)
+	m.parseModes = append(m.parseModes, opts.ParseMode)
+	if len(m.errs) == 0 {
+		return len(m.calls), nil
+	}
+	err := m.errs[0]
+	m.errs = m.errs[1:]
+	return 0, err
+}
+
+func (m *scriptedBotClient) SendDocument(ctx context.Context, chatID int64, name string, data []byte, opts bot.SendOptions) (int, error) {
+	return m.SendMessageWithOptions(ctx, chatID, name, opts)
+}
+
+func (m *scriptedBotClient) ResolveChat(ctx context.Context, username string) (int64, error) {
+	return 0, errors.New("not supported")
+}
+
+func (m *scriptedBotClient) TestConnection(ctx context.Context) error {
+	return nil
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		expectKind      bot.ErrorKind
+		expectRetryable bool
+		expectRetry     time.Duration
+	}{
+		{
+			name:            "rate_limited",
+			err:             &tgbotapi.Error{Code: 429, Message: "Too Many Requests: retry after 3", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 3}},
+			expectKind:      bot.KindRateLimited,
+			expectRetryable: true,
+			expectRetry:     3 * time.Second,
+		},
+		{
+			name:            "bot_blocked",
+			err:             &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"},
+			expectKind:      bot.KindForbidden,
+			expectRetryable: false,
+		},
+		{
+			name:            "invalid_token",
+			err:             &tgbotapi.Error{Code: 401, Message: "Unauthorized"},
+			expectKind:      bot.KindUnauthorized,
+			expectRetryable: false,
+		},
+		{
+			name:            "chat_not_found",
+			err:             &tgbotapi.Error{Code: 400, Message: "Bad Request: chat not found"},
+			expectKind:      bot.KindBadRequest,
+			expectRetryable: false,
+		},
+		{
+			name:            "server_error",
+			err:             &tgbotapi.Error{Code: 502, Message: "Bad Gateway"},
+			expectKind:      bot.KindServer,
+			expectRetryable: true,
+		},
+		{
+			name:            "unknown_error",
+			err:             errors.New("unexpected EOF"),
+			expectKind:      bot.KindUnknown,
+			expectRetryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bot.ClassifyError(tt.err)
+
+			var sendErr *bot.SendError
+			if !errors.As(err, &sendErr) {
+				t.Fatalf("Expected *SendError, got %T", err)
+			}
+			if sendErr.Kind != tt.expectKind {
+				t.Errorf("Expected kind %q, got %q", tt.expectKind, sendErr.Kind)
+			}
+			if bot.IsRetryable(err) != tt.expectRetryable {
+				t.Errorf("Expected retryable = %v", tt.expectRetryable)
+			}
+			if bot.RetryAfter(err) != tt.expectRetry {
+				t.Errorf("Expected retry after %v, got %v", tt.expectRetry, bot.RetryAfter(err))
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Expected classified error to wrap the original")
+			}
+		})
+	}
+}
+
+func TestRetryClassification(t *testing.T) {
+	tests := []struct {
+		name        string
+		errs        []error
+		expectErr   bool
+		expectCalls int
+	}{
+		{
+			name:        "permanent_error_not_retried",
+			errs:        []error{&bot.SendError{Kind: bot.KindForbidden, Code: 403}},
+			expectErr:   true,
+			expectCalls: 1,
+		},
+		{
+			name:        "transient_error_retried",
+			errs:        []error{&bot.SendError{Kind: bot.KindNetwork}, &bot.SendError{Kind: bot.KindServer, Code: 502}},
+			expectErr:   false,
+			expectCalls: 3,
+		},
+		{
+			name: "retries_exhausted",
+			errs: []error{
+				&bot.SendError{Kind: bot.KindNetwork},
+				&bot.SendError{Kind: bot.KindNetwork},
+				&bot.SendError{Kind: bot.KindNetwork},
+				&bot.SendError{Kind: bot.KindNetwork},
+			},
+			expectErr:   true,
+			expectCalls: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			botClient := &scriptedBotClient{errs: tt.errs}
+			client := newTestClient(t, botClient)
+
+			err := client.ReportError(context.Background(), errors.New("test error"), "test_type")
+			if tt.expectErr && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if len(botClient.calls) != tt.expectCalls {
+				t.Errorf("Expected %d calls, got %d", tt.expectCalls, len(botClient.calls))
+			}
+		})
+	}
+}
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	botClient := &scriptedBotClient{errs: []error{
+		&bot.SendError{Kind: bot.KindRateLimited, Code: 429, RetryAfter: 50 * time.Millisecond},
+	}}
+	client := newTestClient(t, botClient, telegramity.WithRetryDelay(time.Millisecond))
+
+	if err := client.ReportError(context.Background(), errors.New("test error"), "test_type"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(botClient.calls) != 2 {
+		t.Fatalf("Expected 2 calls, got %d", len(botClient.calls))
+	}
+	if waited := botClient.calls[1].Sub(botClient.calls[0]); waited < 50*time.Millisecond {
+		t.Errorf("Expected to wait at least 50ms before retrying, waited %v", waited)
+	}
+}
+
+func TestRetryAfterPausesEverySender(t *testing.T) {
+	botClient := &scriptedBotClient{errs: []error{
+		&bot.SendError{Kind: bot.KindRateLimited, Code: 429, RetryAfter: 50 * time.Millisecond},
+	}}
+	client := newTestClient(t, botClient, telegramity.WithMaxRetries(0))
+
+	// The first report gives up, but the flood wait still holds for the next
+	if err := client.ReportError(context.Background(), errors.New("first"), "test_type"); err == nil {
+		t.Fatal("Expected the first report to fail")
+	}
+	if err := client.ReportError(context.Background(), errors.New("second"), "test_type"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(botClient.calls) != 2 {
+		t.Fatalf("Expected 2 calls, got %d", len(botClient.calls))
+	}
+	if waited := botClient.calls[1].Sub(botClient.calls[0]); waited < 50*time.Millisecond {
+		t.Errorf("Expected the second report to wait at least 50ms, waited %v", waited)
+	}
+}
+
+func TestParseErrorFallsBackToPlainText(t *testing.T) {
+	botClient := &scriptedBotClient{errs: []error{
+		&bot.SendError{Kind: bot.KindBadRequest, Code: 400, Description: "Bad Request: can't parse entities: unsupported start tag"},
+	}}
+	client := newTestClient(t, botClient)
+
+	if err := client.ReportError(context.Background(), errors.New("test error"), "test_type"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(botClient.calls) != 2 {
+		t.Fatalf("Expected 2 calls, got %d", len(botClient.calls))
+	}
+	if botClient.parseModes[1] != bot.ParseModeNone {
+		t.Errorf("Expected plain text resend, got parse mode %q", botClient.parseModes[1])
+	}
+	if strings.Contains(botClient.messages[1], "<b>") {
+		t.Errorf("Expected tags to be stripped, got:\n%s", botClient.messages[1])
+	}
+}
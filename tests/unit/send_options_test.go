@@ -0,0 +1,159 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	internalerrors "github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/internal/telegram/bot"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+func TestSendOptions(t *testing.T) {
+	night := time.Date(2026, 1, 1, 23, 30, 0, 0, time.UTC)
+	day := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	config := []configs.ConfigOption{
+		telegramity.WithForumTopic(telegramity.ErrorTypePayment, 7),
+		telegramity.WithForumTopic("", 1),
+		telegramity.WithSilentBelow(telegramity.SeverityMedium),
+		telegramity.WithQuietHours(22, 7, nil, telegramity.SeverityCritical),
+		telegramity.WithLinkPreviews(false),
+	}
+
+	tests := []struct {
+		name      string
+		errorType string
+		opts      []internalerrors.ErrorOption
+		want      bot.SendOptions
+	}{
+		{
+			name:      "topic_by_error_type",
+			errorType: telegramity.ErrorTypePayment,
+			opts:      []internalerrors.ErrorOption{telegramity.WithTimestamp(day)},
+			want:      bot.SendOptions{ThreadID: 7},
+		},
+		{
+			name:      "default_topic",
+			errorType: telegramity.ErrorTypeAuth,
+			opts:      []internalerrors.ErrorOption{telegramity.WithTimestamp(day)},
+			want:      bot.SendOptions{ThreadID: 1},
+		},
+		{
+			name:      "topic_per_report",
+			errorType: telegramity.ErrorTypePayment,
+			opts:      []internalerrors.ErrorOption{telegramity.WithTimestamp(day), telegramity.WithTopic(9)},
+			want:      bot.SendOptions{ThreadID: 9},
+		},
+		{
+			name:      "low_severity_silent",
+			errorType: telegramity.ErrorTypeAuth,
+			opts:      []internalerrors.ErrorOption{telegramity.WithTimestamp(day), telegramity.WithSeverity(telegramity.SeverityLow)},
+			want:      bot.SendOptions{ThreadID: 1, Silent: true},
+		},
+		{
+			name:      "high_severity_silent_at_night",
+			errorType: telegramity.ErrorTypeAuth,
+			opts:      []internalerrors.ErrorOption{telegramity.WithTimestamp(night), telegramity.WithSeverity(telegramity.SeverityHigh)},
+			want:      bot.SendOptions{ThreadID: 1, Silent: true},
+		},
+		{
+			name:      "critical_loud_at_night",
+			errorType: telegramity.ErrorTypeAuth,
+			opts:      []internalerrors.ErrorOption{telegramity.WithTimestamp(night), telegramity.WithSeverity(telegramity.SeverityCritical)},
+			want:      bot.SendOptions{ThreadID: 1},
+		},
+		{
+			name:      "silent_and_protected_per_report",
+			errorType: telegramity.ErrorTypeAuth,
+			opts: []internalerrors.ErrorOption{
+				telegramity.WithTimestamp(day),
+				telegramity.WithSeverity(telegramity.SeverityCritical),
+				telegramity.WithSilent(),
+				telegramity.WithProtectedContent(),
+			},
+			want: bot.SendOptions{ThreadID: 1, Silent: true, ProtectContent: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			botClient := &recordingBotClient{}
+			client := newTestClient(t, botClient, config...)
+
+			if err := client.ReportError(context.Background(), errors.New("boom"), tt.errorType, tt.opts...); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			sent := botClient.sentOptions()
+			if len(sent) != 1 {
+				t.Fatalf("Expected 1 message, got %d", len(sent))
+			}
+			want := tt.want
+			want.ParseMode = bot.ParseModeHTML
+			want.DisableLinkPreview = true
+			if sent[0] != want {
+				t.Errorf("Expected %+v, got %+v", want, sent[0])
+			}
+		})
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		name  string
+		quiet configs.QuietHours
+		at    time.Time
+		want  bool
+	}{
+		{name: "disabled", quiet: configs.QuietHours{}, at: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), want: false},
+		{name: "same_day_inside", quiet: configs.QuietHours{Start: 12, End: 14}, at: time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC), want: true},
+		{name: "same_day_end_excluded", quiet: configs.QuietHours{Start: 12, End: 14}, at: time.Date(2026, 1, 1, 14, 0, 0, 0, time.UTC), want: false},
+		{name: "wraps_midnight", quiet: configs.QuietHours{Start: 22, End: 7}, at: time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC), want: true},
+		{name: "wraps_midnight_outside", quiet: configs.QuietHours{Start: 22, End: 7}, at: time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC), want: false},
+		{name: "location", quiet: configs.QuietHours{Start: 22, End: 7, Location: tokyo}, at: time.Date(2026, 1, 1, 14, 0, 0, 0, time.UTC), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quiet.Contains(tt.at); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTopicsPerChat(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient,
+		telegramity.WithForumTopic(telegramity.ErrorTypePayment, 7),
+		telegramity.WithRoutes(
+			telegramity.Route{ErrorTypes: []string{telegramity.ErrorTypePayment}, ChatIDs: []int64{555}, TopicID: 3},
+			telegramity.Route{MinSeverity: telegramity.SeverityCritical, ChatIDs: []int64{777, 123456789}},
+		),
+	)
+
+	err := client.ReportError(context.Background(), errors.New("boom"), telegramity.ErrorTypePayment,
+		telegramity.WithSeverity(telegramity.SeverityCritical),
+		telegramity.WithTopic(9),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Topic IDs of one forum must not leak into the other chats
+	want := map[int64]int{555: 3, 777: 0, 123456789: 9}
+	chatIDs, sent := botClient.sentTo(), botClient.sentOptions()
+	if len(chatIDs) != len(want) {
+		t.Fatalf("Expected %d messages, got %v", len(want), chatIDs)
+	}
+	for i, chatID := range chatIDs {
+		if sent[i].ThreadID != want[chatID] {
+			t.Errorf("Expected topic %d in chat %d, got %d", want[chatID], chatID, sent[i].ThreadID)
+		}
+	}
+}
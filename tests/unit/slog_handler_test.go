@@ -0,0 +1,122 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+func TestSlogHandlerLevels(t *testing.T) {
+	tests := []struct {
+		name           string
+		level          slog.Level
+		expectReports  int
+		expectSeverity string
+	}{
+		{name: "info_not_reported", level: slog.LevelInfo, expectReports: 0},
+		{name: "warn_medium", level: slog.LevelWarn, expectReports: 1, expectSeverity: "medium"},
+		{name: "error_high", level: slog.LevelError, expectReports: 1, expectSeverity: "high"},
+		{name: "custom_level_critical", level: slog.LevelError + 4, expectReports: 1, expectSeverity: "critical"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			botClient := &recordingBotClient{}
+			client := newTestClient(t, botClient)
+
+			var buf bytes.Buffer
+			next := slog.NewTextHandler(&buf, nil)
+			logger := slog.New(telegramity.NewSlogHandler(client, next, telegramity.SlogHandlerOptions{
+				Level: slog.LevelWarn,
+			}))
+
+			logger.Log(context.Background(), tt.level, "something happened")
+
+			if !strings.Contains(buf.String(), "something happened") {
+				t.Errorf("Expected record to reach the wrapped handler")
+			}
+
+			messages := botClient.sent()
+			if len(messages) != tt.expectReports {
+				t.Fatalf("Expected %d reports, got %d", tt.expectReports, len(messages))
+			}
+			if tt.expectReports > 0 && !strings.Contains(messages[0], "Severity:</b> "+tt.expectSeverity) {
+				t.Errorf("Expected severity %q in report, got:\n%s", tt.expectSeverity, messages[0])
+			}
+		})
+	}
+}
+
+func TestSlogHandlerAttributes(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient)
+
+	logger := slog.New(telegramity.NewSlogHandler(client, nil, telegramity.SlogHandlerOptions{}))
+	logger = logger.With("service", "billing").WithGroup("req").With("id", 7)
+
+	logger.Error("charge failed",
+		"err", errors.New("card declined"),
+		slog.Group("card", "brand", "visa"),
+	)
+
+	messages := botClient.sent()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 report, got %d", len(messages))
+	}
+
+	for _, want := range []string{"charge failed: card declined", "service: billing", "req.id: 7", "req.card.brand: visa"} {
+		if !strings.Contains(messages[0], want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, messages[0])
+		}
+	}
+	if strings.Contains(messages[0], "req.err") {
+		t.Errorf("Expected error attribute to be used as the error, not context")
+	}
+}
+
+func TestSlogHandlerErrorFromWithAttrs(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient)
+
+	logger := slog.New(telegramity.NewSlogHandler(client, nil, telegramity.SlogHandlerOptions{}))
+	logger.With("err", errors.New("connection reset"), "service", "billing").Error("sync failed")
+	logger.With("err", errors.New("connection reset")).Error("retry failed", "cause", errors.New("timeout"))
+
+	messages := botClient.sent()
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 reports, got %d", len(messages))
+	}
+	if !strings.Contains(messages[0], "sync failed: connection reset") || strings.Contains(messages[0], "err: ") {
+		t.Errorf("Expected the WithAttrs error to be the reported error, got:\n%s", messages[0])
+	}
+	// The record's own error wins; the handler's stays in the context
+	if !strings.Contains(messages[1], "retry failed: timeout") || !strings.Contains(messages[1], "err: connection reset") {
+		t.Errorf("Expected the record error to be reported, got:\n%s", messages[1])
+	}
+}
+
+func TestSlogHandlerZeroTime(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient)
+	handler := telegramity.NewSlogHandler(client, nil, telegramity.SlogHandlerOptions{})
+
+	// A zero time must be ignored, as slog.Handler requires
+	record := slog.NewRecord(time.Time{}, slog.LevelError, "no time", 0)
+	if err := handler.Handle(context.Background(), record); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	messages := botClient.sent()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 report, got %d", len(messages))
+	}
+	if !strings.Contains(messages[0], time.Now().Format("2006-01-02")) {
+		t.Errorf("Expected the report to have the current time, got:\n%s", messages[0])
+	}
+}
@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	internalerrors "github.com/somosbytes/telegramity/internal/errors"
+	"github.com/somosbytes/telegramity/internal/formatters"
+	internaltelegramity "github.com/somosbytes/telegramity/internal/telegramity"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+func TestTemplateFormatter(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   []configs.ConfigOption
+		errorType string
+		want      string // Expected message; empty means the built-in layout
+	}{
+		{
+			name: "per_type_template",
+			options: []configs.ConfigOption{
+				telegramity.WithTemplate(telegramity.ErrorTypePayment, `💳 {{ code .Context.order_id }} {{ .Context.amount }} {{ escape .Error }}`),
+				telegramity.WithTemplate("", `{{ escape .Error }}`),
+			},
+			errorType: telegramity.ErrorTypePayment,
+			want:      "💳 <code>A&lt;1&gt;</code> 9.99 charge failed: &lt;declined&gt;",
+		},
+		{
+			name: "global_template",
+			options: []configs.ConfigOption{
+				telegramity.WithTemplate(telegramity.ErrorTypePayment, `💳 {{ .Context.amount }}`),
+				telegramity.WithTemplate("", `<b>{{ .ErrorType }}</b> {{ truncate 10 .Error }}`),
+			},
+			errorType: telegramity.ErrorTypeAuth,
+			want:      "<b>auth</b> charge fa…",
+		},
+		{
+			name: "truncate_escapes",
+			options: []configs.ConfigOption{
+				telegramity.WithTemplate("", `{{ truncate 50 .Error }} {{ truncate 2 .Context.order_id }}`),
+			},
+			errorType: telegramity.ErrorTypeAuth,
+			want:      "charge failed: &lt;declined&gt; A…",
+		},
+		{
+			name: "no_matching_template",
+			options: []configs.ConfigOption{
+				telegramity.WithTemplate(telegramity.ErrorTypePayment, `💳 {{ .Context.amount }}`),
+			},
+			errorType: telegramity.ErrorTypeAuth,
+		},
+		{
+			name: "too_long_falls_back",
+			options: []configs.ConfigOption{
+				telegramity.WithTemplate("", `{{ printf "%05000d" 1 }}`),
+			},
+			errorType: telegramity.ErrorTypeAuth,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configs.DefaultConfig()
+			for _, option := range tt.options {
+				option(&config)
+			}
+
+			formatter, err := formatters.NewTemplateFormatter(&config)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			report := internalerrors.CreateErrorReport(errors.New("charge failed: <declined>"), tt.errorType,
+				telegramity.WithContext(map[string]interface{}{"order_id": "A<1>", "amount": 9.99}),
+			)
+			messages, err := formatter.Format(report)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(messages) != 1 || messages[0].ParseMode != configs.ParseModeHTML {
+				t.Fatalf("Expected one HTML message, got %+v", messages)
+			}
+
+			if tt.want == "" {
+				if !strings.Contains(messages[0].Text, "<b>Error Report</b>") {
+					t.Errorf("Expected the built-in layout, got:\n%s", messages[0].Text)
+				}
+			} else if messages[0].Text != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, messages[0].Text)
+			}
+		})
+	}
+}
+
+func TestTemplateValidation(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "auth.tmpl")
+	if err := os.WriteFile(valid, []byte(`🔐 {{ escape .UserID }} {{ since .Timestamp }}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		option      configs.ConfigOption
+		expectError string
+	}{
+		{
+			name:        "syntax_error",
+			option:      telegramity.WithTemplate("", `{{ .Error `),
+			expectError: "invalid message template",
+		},
+		{
+			name:        "unknown_field",
+			option:      telegramity.WithTemplate(telegramity.ErrorTypeAuth, `{{ .Amount }}`),
+			expectError: "can't evaluate field Amount",
+		},
+		{
+			name:        "unknown_function",
+			option:      telegramity.WithTemplate("", `{{ html5 .Error }}`),
+			expectError: `function "html5" not defined`,
+		},
+		{
+			name:        "missing_file",
+			option:      telegramity.WithTemplateFile("", filepath.Join(dir, "missing.tmpl")),
+			expectError: "no such file",
+		},
+		{
+			name:   "valid_file",
+			option: telegramity.WithTemplateFile(telegramity.ErrorTypeAuth, valid),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.expectError == "" {
+				config := configs.DefaultConfig()
+				tt.option(&config)
+				if _, err := formatters.NewTemplateFormatter(&config); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+
+			// Templates are checked before the bot is contacted
+			_, err := internaltelegramity.NewClient("test_token", 123456789, tt.option)
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Fatalf("Expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
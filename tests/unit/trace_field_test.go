@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/somosbytes/telegramity/internal/configs"
+	"github.com/somosbytes/telegramity/pkg/telegramity"
+)
+
+// withTrace is a context extractor that sets fixed trace fields, standing
+// in for the OpenTelemetry extractor of package telegramityotel
+func withTrace(traceID, spanID, traceURL string) configs.ConfigOption {
+	return telegramity.WithContextExtractors(func(ctx context.Context, report *telegramity.ErrorReport) {
+		report.TraceID, report.SpanID, report.TraceURL = traceID, spanID, traceURL
+	})
+}
+
+func TestTraceField(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+
+	tests := []struct {
+		name    string
+		options []configs.ConfigOption
+		want    string
+	}{
+		{
+			name:    "html",
+			options: []configs.ConfigOption{withTrace(traceID, spanID, "https://ui/trace/"+traceID+"?x=1&y=2")},
+			want:    `<b>Trace:</b> <a href="https://ui/trace/` + traceID + `?x=1&amp;y=2">` + traceID + `</a> (span ` + spanID + ")\n",
+		},
+		{
+			name:    "html_without_url",
+			options: []configs.ConfigOption{withTrace(traceID, spanID, "")},
+			want:    "<b>Trace:</b> " + traceID + " (span " + spanID + ")\n",
+		},
+		{
+			name:    "markdown_v2",
+			options: []configs.ConfigOption{telegramity.WithMarkdownV2Formatter(), withTrace(traceID, spanID, "https://ui/(x)/"+traceID)},
+			want:    "*Trace:* [" + traceID + "](https://ui/(x\\)/" + traceID + ") \\(span " + spanID + "\\)\n",
+		},
+		{
+			name:    "plain_text",
+			options: []configs.ConfigOption{telegramity.WithPlainTextFormatter(), withTrace(traceID, spanID, "https://ui/(x)/"+traceID)},
+			want:    "Trace: " + traceID + " (https://ui/(x)/" + traceID + ") (span " + spanID + ")\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			botClient := &recordingBotClient{}
+			client := newTestClient(t, botClient, tt.options...)
+
+			if err := client.ReportError(context.Background(), errors.New("boom"), "test_type"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if sent := botClient.sent(); len(sent) != 1 || !strings.Contains(sent[0], tt.want) {
+				t.Errorf("Expected message to contain %q, got %q", tt.want, sent)
+			}
+		})
+	}
+}
+
+func TestTraceFieldWithoutTrace(t *testing.T) {
+	botClient := &recordingBotClient{}
+	client := newTestClient(t, botClient)
+
+	if err := client.ReportError(context.Background(), errors.New("boom"), "test_type"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sent := botClient.sent(); len(sent) != 1 || strings.Contains(sent[0], "<b>Trace:</b>") {
+		t.Errorf("Expected no trace field, got %q", sent)
+	}
+}